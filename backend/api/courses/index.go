@@ -1,30 +1,23 @@
 package courses
 
 import (
+	"backend/config"
+	"backend/enroll"
+	"backend/server/standalone"
+	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
-
-	"github.com/corpix/uarand"
-	"github.com/go-resty/resty/v2"
 )
 
-const apiURL = "https://public.enroll.wisc.edu/api/search/v1"
-
 // Term holds term code and short description.
 type Term struct {
 	TermCode         string `json:"termCode"`
 	ShortDescription string `json:"shortDescription"`
 }
 
-func getRandomUserAgent() string {
-	return uarand.GetRandom()
-}
-
 // expandSeasonAbbreviation expands abbreviated season names in the short description.
 func expandSeasonAbbreviation(shortDesc string) string {
 	replacements := map[string]string{
@@ -37,70 +30,28 @@ func expandSeasonAbbreviation(shortDesc string) string {
 	return shortDesc
 }
 
-// fetchCourses queries the courses API
-func fetchCourses(query string, page int, pageSize int, termCode string) (map[string]interface{}, error) {
-	client := resty.New()
-
-	payload := map[string]interface{}{
-		"selectedTerm": termCode,
-		"queryString":  query,
-		"filters": []map[string]interface{}{
-			{
-				"has_child": map[string]interface{}{
-					"type": "enrollmentPackage",
-					"query": map[string]interface{}{
-						"bool": map[string]interface{}{
-							"must": []map[string]interface{}{
-								{"match": map[string]interface{}{"published": true}},
-							},
-						},
-					},
-				},
-			},
+// fetchCourses queries the courses API for published courses matching query.
+func fetchCourses(ctx context.Context, query string, page int, pageSize int, termCode string) (*enroll.SearchResponse, error) {
+	return enroll.Default().Search(ctx, enroll.SearchRequest{
+		SelectedTerm: termCode,
+		QueryString:  query,
+		Filters: []enroll.Filter{
+			enroll.PackageFilter(enroll.MatchField("published", true)),
 		},
-		"page":      page,
-		"pageSize":  pageSize,
-		"sortOrder": "SCORE",
-	}
-
-	resp, err := client.R().
-		SetHeaders(map[string]string{
-			"Accept":       "application/json, text/plain, */*",
-			"Content-Type": "application/json",
-			"User-Agent":   getRandomUserAgent(),
-			"Origin":       "https://public.enroll.wisc.edu",
-			"Referer":      fmt.Sprintf("https://public.enroll.wisc.edu/search?term=%s&keywords=%s", termCode, query),
-		}).
-		SetBody(payload).
-		Post(apiURL)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode())
-	}
-
-	var result map[string]interface{}
-	err = json.Unmarshal(resp.Body(), &result)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
+		Page:      page,
+		PageSize:  pageSize,
+		SortOrder: enroll.SortScore,
+	})
 }
 
 // Handler is the API endpoint handler for /api/courses.
 func Handler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	handler.ServeHTTP(w, r)
+}
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
+var handler = standalone.Handler(http.HandlerFunc(searchCourses), http.MethodGet)
 
+func searchCourses(w http.ResponseWriter, r *http.Request) {
 	// Get the query parameter; default to "*" if not provided.
 	query := r.URL.Query().Get("query")
 	if query == "" {
@@ -117,25 +68,22 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		pageSize = 50
 	}
 
-	// 1. Read environment variables for term code & short description.
-	//    If they are empty, fall back to defaults.
-	termCode := os.Getenv("TERM_CODE")
-	if termCode == "" {
-		termCode = "1262"
-	}
-	termShortDesc := os.Getenv("TERM_SHORT_DESCRIPTION")
-	if termShortDesc == "" {
-		termShortDesc = "Term 1262"
+	// 1. Read the configured term code & short description.
+	cfg, err := config.Default()
+	if err != nil {
+		http.Error(w, "Failed to load configuration", http.StatusInternalServerError)
+		log.Println("Config error:", err)
+		return
 	}
 
-	// 2. Create a Term struct from these variables.
+	// 2. Create a Term struct from these settings.
 	term := &Term{
-		TermCode:         termCode,
-		ShortDescription: termShortDesc,
+		TermCode:         cfg.Term.Code,
+		ShortDescription: cfg.Term.ShortDescription,
 	}
 
-	// 3. Fetch courses using the environment-based term code.
-	courses, err := fetchCourses(query, page, pageSize, term.TermCode)
+	// 3. Fetch courses using the configured term code.
+	courses, err := fetchCourses(r.Context(), query, page, pageSize, term.TermCode)
 	if err != nil {
 		http.Error(w, "Failed to fetch courses", http.StatusInternalServerError)
 		log.Println("Error fetching courses:", err)
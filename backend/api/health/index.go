@@ -0,0 +1,128 @@
+// Package health reports on the running backend for load balancers and
+// uptime checks.
+package health
+
+import (
+	"backend/config"
+	"backend/enroll"
+	"backend/notify"
+	"backend/store"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// ReadyResponse lists the outcome, "ok" or "fail", of each readiness check.
+// Status is "ok" only if every check passed.
+type ReadyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// VersionResponse describes the running build.
+type VersionResponse struct {
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// Live reports that the process is up and serving requests. It checks no
+// dependencies, so that a database outage does not get the server restarted.
+func Live(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// Ready reports whether the backend can serve traffic: its configuration
+// loads and is valid, and the database answers. With READY_PROBE_UPSTREAMS
+// set, it also probes the enroll API and the email provider. It responds 503
+// if any check fails; the reasons are logged rather than shown to the caller.
+func Ready(w http.ResponseWriter, r *http.Request) {
+	response := ReadyResponse{Status: "ok", Checks: map[string]string{}}
+
+	cfg, cfgErr := config.Default()
+	timeout := config.Defaults().Health.Timeout
+	if cfgErr == nil {
+		timeout = cfg.Health.Timeout
+	}
+
+	check := func(name string, probe func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := probe(ctx); err != nil {
+			response.Status = "fail"
+			response.Checks[name] = "fail"
+			log.Printf("Readiness check %s failed: %v\n", name, err)
+			return
+		}
+		response.Checks[name] = "ok"
+	}
+
+	check("config", func(ctx context.Context) error {
+		if cfgErr != nil {
+			return cfgErr
+		}
+		return cfg.Validate()
+	})
+	check("database", func(ctx context.Context) error {
+		st, err := store.Default()
+		if err != nil {
+			return err
+		}
+		return st.Ping(ctx)
+	})
+
+	if cfgErr == nil && cfg.Health.ProbeUpstreams {
+		check("enroll", func(ctx context.Context) error {
+			return enroll.Default().Ping(ctx, cfg.Term.Code)
+		})
+
+		// Only providers that can be checked without sending mail are probed.
+		if n, err := notify.Default(); err != nil {
+			check("email", func(ctx context.Context) error { return err })
+		} else if p, ok := n.(notify.Pinger); ok {
+			check("email", p.Ping)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if response.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Version returns the module version and VCS details embedded in the binary
+// by the Go toolchain.
+func Version(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		http.Error(w, "Build information is unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	response := VersionResponse{
+		Module:    info.Main.Path,
+		Version:   info.Main.Version,
+		GoVersion: info.GoVersion,
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			response.Revision = s.Value
+		case "vcs.time":
+			response.Time = s.Value
+		case "vcs.modified":
+			response.Modified = s.Value == "true"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"backend/config"
+	"backend/enroll"
+	"backend/enroll/enrolltest"
+	"backend/notify"
+	"backend/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// validConfig installs a valid configuration with an in-memory store, and
+// returns it for the test to adjust.
+func validConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.Store.Driver = "memory"
+	cfg.Notify.Provider = "log"
+	config.SetDefault(cfg)
+	store.SetDefault(store.NewMemory())
+	notify.SetDefault(notify.Log{})
+	return cfg
+}
+
+func ready(t *testing.T) (int, ReadyResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var response ReadyResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, response
+}
+
+func TestLive(t *testing.T) {
+	w := httptest.NewRecorder()
+	Live(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("Live = %d %q, want 200 ok", w.Code, w.Body)
+	}
+}
+
+func TestReady(t *testing.T) {
+	validConfig(t)
+
+	code, response := ready(t)
+	want := map[string]string{"config": "ok", "database": "ok"}
+	if code != http.StatusOK || response.Status != "ok" || !maps.Equal(response.Checks, want) {
+		t.Errorf("Ready = %d %+v, want 200 with checks %v", code, response, want)
+	}
+}
+
+func TestReadyFailsOnInvalidConfig(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Check.Concurrency = 0
+
+	code, response := ready(t)
+	if code != http.StatusServiceUnavailable || response.Status != "fail" || response.Checks["config"] != "fail" {
+		t.Errorf("Ready = %d %+v, want 503 with config failing", code, response)
+	}
+	if response.Checks["database"] != "ok" {
+		t.Errorf("database check = %q, want ok", response.Checks["database"])
+	}
+}
+
+func TestReadyFailsWhenConfigDoesNotLoad(t *testing.T) {
+	config.SetDefault(nil)
+	t.Cleanup(func() { config.SetDefault(nil) })
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("STORE", "postgres")
+	t.Setenv("POSTGRES_URL", "")
+	store.SetDefault(store.NewMemory())
+
+	code, response := ready(t)
+	if code != http.StatusServiceUnavailable || response.Checks["config"] != "fail" {
+		t.Errorf("Ready = %d %+v, want 503 with config failing", code, response)
+	}
+}
+
+// downStore is a store whose database does not answer.
+type downStore struct {
+	*store.Memory
+}
+
+func (downStore) Ping(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestReadyFailsWhenDatabaseIsDown(t *testing.T) {
+	validConfig(t)
+	store.SetDefault(downStore{store.NewMemory()})
+
+	code, response := ready(t)
+	if code != http.StatusServiceUnavailable || response.Checks["database"] != "fail" || response.Checks["config"] != "ok" {
+		t.Errorf("Ready = %d %+v, want 503 with database failing", code, response)
+	}
+}
+
+func TestReadyProbesUpstreams(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Health.ProbeUpstreams = true
+	api := enrolltest.NewServer()
+	t.Cleanup(api.Close)
+	enroll.SetDefault(api.Client())
+
+	code, response := ready(t)
+	want := map[string]string{"config": "ok", "database": "ok", "enroll": "ok"}
+	if code != http.StatusOK || !maps.Equal(response.Checks, want) {
+		t.Errorf("Ready = %d %+v, want 200 with checks %v", code, response, want)
+	}
+
+	api.Close()
+	code, response = ready(t)
+	if code != http.StatusServiceUnavailable || response.Checks["enroll"] != "fail" {
+		t.Errorf("Ready with the enroll API down = %d %+v, want 503 with enroll failing", code, response)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	w := httptest.NewRecorder()
+	Version(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Version = %d %q", w.Code, w.Body)
+	}
+	var response VersionResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.GoVersion == "" {
+		t.Errorf("Version = %+v, want the Go version", response)
+	}
+}
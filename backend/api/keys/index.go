@@ -0,0 +1,186 @@
+// Package keys manages the API keys of the authenticated caller. Keys can
+// only be managed by a signed-in user, not with another API key.
+package keys
+
+import (
+	"backend/auth"
+	"backend/server/standalone"
+	"backend/store"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIKey describes one of the caller's API keys. Key is only set in the
+// response that issues it; it cannot be retrieved again.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	Key        string     `json:"key,omitempty"`
+}
+
+// KeysResponse wraps the keys array.
+type KeysResponse struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// IssuePayload defines the JSON structure for issuing a key. Scopes must be
+// a non-empty subset of read:subscriptions and write:subscriptions.
+type IssuePayload struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// Handler is the API endpoint handler for /api/keys: GET lists the caller's
+// keys, POST issues a new one, and DELETE ?id= revokes one. It authenticates
+// the caller itself, requiring the manage:keys scope that only a signed-in
+// user holds, and applies the CORS policy when deployed on its own, so that
+// it behaves the same however it is mounted.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler.ServeHTTP(w, r)
+}
+
+var handler = standalone.Handler(
+	auth.RequireUser(auth.ScopeManageKeys)(http.HandlerFunc(manageKeys)),
+	http.MethodGet, http.MethodPost, http.MethodDelete,
+)
+
+func manageKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listKeys(w, r)
+	case http.MethodPost:
+		issueKey(w, r)
+	case http.MethodDelete:
+		revokeKey(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// listKeys returns the authenticated caller's active API keys.
+func listKeys(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFrom(r.Context())
+
+	st, err := store.Default()
+	if err != nil {
+		http.Error(w, "Failed to connect to DB", http.StatusInternalServerError)
+		log.Println("DB connection error:", err)
+		return
+	}
+
+	stored, err := st.ListAPIKeys(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch API keys", http.StatusInternalServerError)
+		log.Println("DB query error:", err)
+		return
+	}
+
+	keys := []APIKey{}
+	for _, k := range stored {
+		keys = append(keys, fromStore(k))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(KeysResponse{Keys: keys})
+}
+
+// issueKey creates an API key for the authenticated caller.
+func issueKey(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFrom(r.Context())
+
+	var payload IssuePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if !auth.ValidKeyScopes(payload.Scopes) {
+		http.Error(w, "scopes must list one or more of read:subscriptions, write:subscriptions", http.StatusBadRequest)
+		return
+	}
+
+	st, err := store.Default()
+	if err != nil {
+		http.Error(w, "Failed to connect to DB", http.StatusInternalServerError)
+		log.Println("DB connection error:", err)
+		return
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		http.Error(w, "Failed to generate API key", http.StatusInternalServerError)
+		log.Println("Key generation error:", err)
+		return
+	}
+	stored, err := st.CreateAPIKey(r.Context(), store.APIKey{
+		UserID: user.ID,
+		Name:   payload.Name,
+		Prefix: prefix,
+		Hash:   hash,
+		Scopes: payload.Scopes,
+	})
+	if err != nil {
+		http.Error(w, "Failed to store API key", http.StatusInternalServerError)
+		log.Println("DB insert error:", err)
+		return
+	}
+	log.Printf("Issued API key %d (%s) to user %d\n", stored.ID, prefix, user.ID)
+
+	issued := fromStore(stored)
+	issued.Key = key
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issued)
+}
+
+// revokeKey revokes the authenticated caller's API key given by the id query
+// parameter.
+func revokeKey(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFrom(r.Context())
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	st, err := store.Default()
+	if err != nil {
+		http.Error(w, "Failed to connect to DB", http.StatusInternalServerError)
+		log.Println("DB connection error:", err)
+		return
+	}
+
+	err = st.RevokeAPIKey(r.Context(), user.ID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		log.Println("DB update error:", err)
+		return
+	}
+	log.Printf("Revoked API key %d of user %d\n", id, user.ID)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("API key revoked"))
+}
+
+func fromStore(k store.APIKey) APIKey {
+	return APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+	}
+}
@@ -0,0 +1,227 @@
+package keys
+
+import (
+	"backend/auth"
+	"backend/server/cors"
+	"backend/store"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client.apps.googleusercontent.com"
+
+// testEnv serves Handler against an in-memory store with two registered
+// users, who sign in with ID tokens signed by a key generated for the test.
+type testEnv struct {
+	st  *store.Memory
+	key *rsa.PrivateKey
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := auth.NewGoogleVerifier(testClientID)
+	v.Keys = auth.StaticKeys{"test-key": &key.PublicKey}
+	auth.SetDefaultGoogle(v)
+
+	cors.SetDefault(cors.Policy{
+		AllowedOrigins: []string{"https://classes.example.com"},
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+	})
+
+	st := store.NewMemory()
+	store.SetDefault(st)
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		if err := st.UpsertUser(context.Background(), store.User{Email: email}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &testEnv{st: st, key: key}
+}
+
+// token returns an ID token for email.
+func (e *testEnv) token(t *testing.T, email string) string {
+	t.Helper()
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"sub":            "sub-" + email,
+		"aud":            testClientID,
+		"email":          email,
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "test-key"
+	raw, err := token.SignedString(e.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func (e *testEnv) call(t *testing.T, method, target, bearer string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := httptest.NewRequest(method, target, &buf)
+	r.Header.Set("Authorization", "Bearer "+bearer)
+	w := httptest.NewRecorder()
+	Handler(w, r)
+	return w
+}
+
+// issue issues a key to the user with the given ID token.
+func (e *testEnv) issue(t *testing.T, bearer string, scopes ...string) APIKey {
+	t.Helper()
+
+	w := e.call(t, http.MethodPost, "/api/keys", bearer, IssuePayload{Name: "laptop", Scopes: scopes})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("issue: status %d: %s", w.Code, w.Body)
+	}
+	var key APIKey
+	if err := json.NewDecoder(w.Body).Decode(&key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func (e *testEnv) list(t *testing.T, bearer string) []APIKey {
+	t.Helper()
+
+	w := e.call(t, http.MethodGet, "/api/keys", bearer, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status %d: %s", w.Code, w.Body)
+	}
+	var resp KeysResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Keys
+}
+
+func TestIssuedKeyIsOnlyShownOnceAndStoredHashed(t *testing.T) {
+	e := newTestEnv(t)
+	alice := e.token(t, "alice@example.com")
+
+	issued := e.issue(t, alice, auth.ScopeReadSubscriptions)
+	if !strings.HasPrefix(issued.Key, issued.Prefix) || len(issued.Key) <= len(issued.Prefix) {
+		t.Fatalf("issued key %q with prefix %q", issued.Key, issued.Prefix)
+	}
+
+	user, _ := e.st.GetUser(context.Background(), "alice@example.com")
+	stored, err := e.st.ListAPIKeys(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || !bytes.Equal(stored[0].Hash, auth.HashAPIKey(issued.Key)) {
+		t.Fatalf("stored keys %+v, want one with the hash of the issued key", stored)
+	}
+	if dump := fmt.Sprintf("%+v", stored[0]); strings.Contains(dump, issued.Key) {
+		t.Errorf("raw key is stored: %s", dump)
+	}
+
+	listed := e.list(t, alice)
+	if len(listed) != 1 || listed[0].ID != issued.ID || listed[0].Key != "" {
+		t.Errorf("listed %+v, want the issued key without its secret", listed)
+	}
+}
+
+func TestListReturnsOnlyCallersKeys(t *testing.T) {
+	e := newTestEnv(t)
+	alice, bob := e.token(t, "alice@example.com"), e.token(t, "bob@example.com")
+
+	aliceKey := e.issue(t, alice, auth.ScopeReadSubscriptions)
+	e.issue(t, bob, auth.ScopeReadSubscriptions)
+
+	listed := e.list(t, alice)
+	if len(listed) != 1 || listed[0].ID != aliceKey.ID {
+		t.Errorf("alice listed %+v, want only her key %d", listed, aliceKey.ID)
+	}
+}
+
+func TestRevokedKeyIsRejectedImmediately(t *testing.T) {
+	e := newTestEnv(t)
+	alice, bob := e.token(t, "alice@example.com"), e.token(t, "bob@example.com")
+
+	issued := e.issue(t, alice, auth.ScopeReadSubscriptions)
+	target := fmt.Sprintf("/api/keys?id=%d", issued.ID)
+	if w := e.call(t, http.MethodDelete, target, bob, nil); w.Code != http.StatusNotFound {
+		t.Errorf("bob revoking alice's key: status %d, want 404", w.Code)
+	}
+	if _, _, err := e.st.UseAPIKey(context.Background(), auth.HashAPIKey(issued.Key)); err != nil {
+		t.Fatalf("key stopped working after another user's revocation: %v", err)
+	}
+
+	if w := e.call(t, http.MethodDelete, target, alice, nil); w.Code != http.StatusOK {
+		t.Fatalf("revoke: status %d: %s", w.Code, w.Body)
+	}
+	if _, _, err := e.st.UseAPIKey(context.Background(), auth.HashAPIKey(issued.Key)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("UseAPIKey after revocation = %v, want ErrNotFound", err)
+	}
+	if w := e.call(t, http.MethodGet, "/api/keys", issued.Key, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked key: status %d, want 401", w.Code)
+	}
+	if listed := e.list(t, alice); len(listed) != 0 {
+		t.Errorf("listed %+v after revocation, want none", listed)
+	}
+}
+
+func TestAPIKeyCannotManageKeys(t *testing.T) {
+	e := newTestEnv(t)
+	alice := e.token(t, "alice@example.com")
+
+	issued := e.issue(t, alice, auth.KeyScopes...)
+	requests := []struct {
+		method, target string
+		body           any
+	}{
+		{http.MethodGet, "/api/keys", nil},
+		{http.MethodPost, "/api/keys", IssuePayload{Name: "minted", Scopes: auth.KeyScopes}},
+		{http.MethodDelete, fmt.Sprintf("/api/keys?id=%d", issued.ID), nil},
+	}
+	for _, req := range requests {
+		if w := e.call(t, req.method, req.target, issued.Key, req.body); w.Code != http.StatusForbidden {
+			t.Errorf("%s with an API key: status %d, want 403", req.method, w.Code)
+		}
+	}
+
+	if listed := e.list(t, alice); len(listed) != 1 {
+		t.Errorf("alice has %d keys, want only the one she issued", len(listed))
+	}
+}
+
+func TestIssueRejectsInvalidScopes(t *testing.T) {
+	e := newTestEnv(t)
+	alice := e.token(t, "alice@example.com")
+
+	for _, scopes := range [][]string{nil, {auth.ScopeManageKeys}, {"admin"}} {
+		w := e.call(t, http.MethodPost, "/api/keys", alice, IssuePayload{Name: "laptop", Scopes: scopes})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("scopes %q: status %d, want 400", scopes, w.Code)
+		}
+	}
+}
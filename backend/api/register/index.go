@@ -1,75 +1,59 @@
 package register
 
 import (
-	"encoding/json"
+	"backend/auth"
+	"backend/server/standalone"
+	"backend/store"
 	"log"
 	"net/http"
-	"os"
-	"time"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type UserPayload struct {
-	Name      string `json:"name,omitempty"`
-	Email     string `json:"email,omitempty"`
-	Image     string `json:"image,omitempty"`
-	GoogleSub string `json:"googleSub,omitempty"`
+// Handler registers the caller identified by the Google ID token in the
+// Authorization header. Email, Google subject, name and picture are taken
+// from the verified token, never from the request body.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler.ServeHTTP(w, r)
 }
 
-type RequestBody struct {
-	User UserPayload `json:"user"`
-}
+var handler = standalone.Handler(http.HandlerFunc(register), http.MethodPost)
 
-func Handler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+func register(w http.ResponseWriter, r *http.Request) {
+	token, ok := auth.BearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Missing Google ID token", http.StatusUnauthorized)
 		return
 	}
 
-	var req RequestBody
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+	verifier, err := auth.DefaultGoogle()
+	if err != nil {
+		http.Error(w, "Failed to configure token verification", http.StatusInternalServerError)
+		log.Println("Google verifier config error:", err)
+		return
+	}
+	id, err := verifier.Verify(r.Context(), token)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "Invalid Google ID token", http.StatusUnauthorized)
+		log.Println("Rejected ID token:", err)
 		return
 	}
 
-	log.Printf("Received user data: %+v\n", req.User)
+	log.Printf("Registering user %s\n", id.Email)
 
-	dbURL := os.Getenv("POSTGRES_URL")
-	pool, err := pgxpool.New(r.Context(), dbURL)
+	st, err := store.Default()
 	if err != nil {
 		http.Error(w, "Failed to connect to DB", http.StatusInternalServerError)
 		log.Println("DB connection error:", err)
 		return
 	}
-	defer pool.Close()
-
-	now := time.Now()
-
-	query := `
-	  INSERT INTO users (email, google_sub, name, image, created_at, last_logged_in)
-	  VALUES ($1, $2, $3, $4, $5, $5)
-	  ON CONFLICT (email)
-	  DO UPDATE SET
-	    google_sub      = EXCLUDED.google_sub,
-	    name            = EXCLUDED.name,
-	    image           = EXCLUDED.image,
-	    last_logged_in  = EXCLUDED.last_logged_in
-	  RETURNING id
-	`
-
-	// Use 'EXCLUDED.last_logged_in = now' so each new sign-in updates last_logged_in
-	// for that user row. 'created_at' remains the original insertion time.
-	// If you want to see the inserted/updated user ID, you can scan it here.
-
-	_, err = pool.Exec(r.Context(), query,
-		req.User.Email,
-		req.User.GoogleSub,
-		req.User.Name,
-		req.User.Image,
-		now, // used for both created_at (initial insert) and last_logged_in
-	)
 
+	err = st.UpsertUser(r.Context(), store.User{
+		Email:     id.Email,
+		GoogleSub: id.Subject,
+		Name:      id.Name,
+		Image:     id.Picture,
+	})
 	if err != nil {
 		http.Error(w, "Failed to insert user", http.StatusInternalServerError)
 		log.Println("DB insert/upsert error:", err)
@@ -0,0 +1,77 @@
+package sections
+
+import (
+	"backend/config"
+	"backend/enroll"
+	"backend/server/standalone"
+	"backend/store"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Section describes one enrollable section combination of a course. Its
+// ClassNumber is what the frontend sends to subscribe to a single section.
+type Section struct {
+	ClassNumber  int          `json:"classNumber"`
+	Sections     string       `json:"sections"`
+	Status       store.Status `json:"status"`
+	OpenSeats    int          `json:"openSeats"`
+	Capacity     int          `json:"capacity"`
+	WaitlistSize int          `json:"waitlistSize"`
+}
+
+// SectionsResponse wraps the sections array.
+type SectionsResponse struct {
+	Sections []Section `json:"sections"`
+}
+
+// Handler is the API endpoint handler for /api/sections.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler.ServeHTTP(w, r)
+}
+
+var handler = standalone.Handler(http.HandlerFunc(listSections), http.MethodGet)
+
+func listSections(w http.ResponseWriter, r *http.Request) {
+	courseID := r.URL.Query().Get("courseId")
+	subjectCode := r.URL.Query().Get("subjectCode")
+	if courseID == "" || subjectCode == "" {
+		http.Error(w, "courseId and subjectCode query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := config.Default()
+	if err != nil {
+		http.Error(w, "Failed to load configuration", http.StatusInternalServerError)
+		log.Println("Config error:", err)
+		return
+	}
+
+	packages, err := enroll.Default().EnrollmentPackages(r.Context(), cfg.Term.Code, subjectCode, courseID)
+	if errors.Is(err, enroll.ErrNotFound) {
+		http.Error(w, "Course not found in this term", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch sections", http.StatusInternalServerError)
+		log.Println("Error fetching sections:", err)
+		return
+	}
+
+	response := SectionsResponse{Sections: []Section{}}
+	for _, p := range packages {
+		response.Sections = append(response.Sections, Section{
+			ClassNumber:  p.EnrollmentClassNumber,
+			Sections:     p.Label(),
+			Status:       store.ParseStatus(p.PackageEnrollmentStatus.Status),
+			OpenSeats:    p.EnrollmentStatus.OpenSeats,
+			Capacity:     p.EnrollmentStatus.Capacity,
+			WaitlistSize: p.EnrollmentStatus.WaitlistCurrentSize,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,95 +1,92 @@
 package subscribe
 
 import (
+	"backend/auth"
+	"backend/server/standalone"
+	"backend/store"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
-	"time"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // SubscriptionPayload defines the JSON structure expected from the frontend.
+// The subscriber is always the authenticated caller.
+// ClassNumber selects a single section to watch; omit it to watch the whole course.
+// NotifyOn lists the statuses to be notified about ("open" for a seat opening,
+// "waitlisted" for a waitlist opening); omit it to hear about every change.
+// MinOpenSeats and WaitlistBelow optionally restrict notifications to when a
+// course has at least that many open seats, or its waitlist drops below that length.
 type SubscriptionPayload struct {
-	UserEmail         string `json:"userEmail"`
-	UserFullName      string `json:"userFullName"`
-	CourseID          string `json:"courseId"`
-	CourseName        string `json:"courseName"`
-	CourseSubjectCode string `json:"courseSubjectCode"`
-	Credits           int    `json:"credits"`
-	Title             string `json:"title"`
+	CourseID          string         `json:"courseId"`
+	CourseName        string         `json:"courseName"`
+	CourseSubjectCode string         `json:"courseSubjectCode"`
+	Credits           int            `json:"credits"`
+	Title             string         `json:"title"`
+	ClassNumber       int            `json:"classNumber,omitempty"`
+	NotifyOn          []store.Status `json:"notifyOn,omitempty"`
+	MinOpenSeats      *int           `json:"minOpenSeats,omitempty"`
+	WaitlistBelow     *int           `json:"waitlistBelow,omitempty"`
 }
 
+// Handler subscribes the authenticated caller to a course or section. It
+// authenticates the caller itself, requiring the write:subscriptions scope,
+// and applies the CORS policy when deployed on its own, so that it behaves
+// the same however it is mounted.
 func Handler(w http.ResponseWriter, r *http.Request) {
-	// Set CORS headers.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
+	handler.ServeHTTP(w, r)
+}
 
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+var handler = standalone.Handler(
+	auth.RequireUser(auth.ScopeWriteSubscriptions)(http.HandlerFunc(subscribe)),
+	http.MethodPost,
+)
 
+func subscribe(w http.ResponseWriter, r *http.Request) {
 	var payload SubscriptionPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Received subscription payload: %+v\n", payload)
+	user, _ := auth.UserFrom(r.Context())
+	log.Printf("Received subscription payload from user %d: %+v\n", user.ID, payload)
 
-	// Connect to the database using the POSTGRES_URL environment variable.
-	dbURL := os.Getenv("POSTGRES_URL")
-	pool, err := pgxpool.New(r.Context(), dbURL)
+	if payload.CourseID == "" || payload.CourseSubjectCode == "" {
+		http.Error(w, "courseId and courseSubjectCode are required", http.StatusBadRequest)
+		return
+	}
+	for _, status := range payload.NotifyOn {
+		if !status.Valid() {
+			http.Error(w, "Invalid notifyOn status: "+string(status), http.StatusBadRequest)
+			return
+		}
+	}
+	if (payload.MinOpenSeats != nil && *payload.MinOpenSeats < 1) ||
+		(payload.WaitlistBelow != nil && *payload.WaitlistBelow < 1) {
+		http.Error(w, "minOpenSeats and waitlistBelow must be positive", http.StatusBadRequest)
+		return
+	}
+
+	st, err := store.Default()
 	if err != nil {
 		http.Error(w, "Failed to connect to DB", http.StatusInternalServerError)
 		log.Println("DB connection error:", err)
 		return
 	}
-	defer pool.Close()
-
-	now := time.Now()
 
-	// Insert a new subscription record.
-	// Since the last_checked column has been dropped, we only reference created_at.
-	query := `
-	INSERT INTO subscriptions (
-	  user_id, user_email, user_fullname, course_id, 
-	  course_name, course_subject_code, created_at,
-	  credits, title
-	)
-	VALUES (
-	  (SELECT id FROM users WHERE email=$1), 
-	  $1, $2, $3, 
-	  $4, $5, $6,
-	  $7, $8
-	)
-	ON CONFLICT (user_id, course_id, course_subject_code)
-	DO UPDATE SET
-	  user_fullname = EXCLUDED.user_fullname,
-	  course_name = EXCLUDED.course_name,
-	  credits = EXCLUDED.credits,
-	  title = EXCLUDED.title
-	`
-	_, err = pool.Exec(
-		r.Context(),
-		query,
-		payload.UserEmail,         // $1
-		payload.UserFullName,      // $2
-		payload.CourseID,          // $3
-		payload.CourseName,        // $4
-		payload.CourseSubjectCode, // $5
-		now,                       // $6 (for created_at)
-		payload.Credits,           // $7
-		payload.Title,             // $8
-	)
+	err = st.AddSubscription(r.Context(), store.Subscription{
+		UserEmail:         user.Email,
+		UserFullName:      user.Name,
+		CourseID:          payload.CourseID,
+		CourseName:        payload.CourseName,
+		CourseSubjectCode: payload.CourseSubjectCode,
+		Credits:           payload.Credits,
+		Title:             payload.Title,
+		ClassNumber:       payload.ClassNumber,
+		NotifyOn:          payload.NotifyOn,
+		MinOpenSeats:      payload.MinOpenSeats,
+		WaitlistBelow:     payload.WaitlistBelow,
+	})
 	if err != nil {
 		http.Error(w, "Failed to subscribe", http.StatusInternalServerError)
 		log.Println("DB insert/upsert error:", err)
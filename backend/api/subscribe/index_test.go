@@ -0,0 +1,55 @@
+package subscribe
+
+import (
+	"backend/auth"
+	"backend/store"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSubscribeValidatesPayload(t *testing.T) {
+	st := store.NewMemory()
+	store.SetDefault(st)
+	ctx := context.Background()
+	if err := st.UpsertUser(ctx, store.User{Email: "student@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := st.GetUser(ctx, "student@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", `{"courseId":"024798","courseSubjectCode":"266","courseName":"COMP SCI 300"}`, http.StatusOK},
+		{"missing course ID", `{"courseSubjectCode":"266"}`, http.StatusBadRequest},
+		{"missing subject code", `{"courseId":"024798"}`, http.StatusBadRequest},
+		{"empty", `{}`, http.StatusBadRequest},
+		{"invalid status", `{"courseId":"024798","courseSubjectCode":"266","notifyOn":["maybe"]}`, http.StatusBadRequest},
+		{"zero threshold", `{"courseId":"024798","courseSubjectCode":"266","minOpenSeats":0}`, http.StatusBadRequest},
+		{"not JSON", `courseId=024798`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(tt.body))
+		r = r.WithContext(auth.WithUser(r.Context(), user))
+		w := httptest.NewRecorder()
+		subscribe(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d (%s), want %d", tt.name, w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+		}
+	}
+
+	subs, err := st.ListSubscriptions(ctx, user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 {
+		t.Errorf("stored %d subscriptions, want only the valid one", len(subs))
+	}
+}
@@ -1,21 +1,25 @@
 package subscriptions
 
 import (
+	"backend/auth"
+	"backend/server/standalone"
+	"backend/store"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Subscription represents a subscription record returned to the frontend.
 type Subscription struct {
-	CourseID          string `json:"courseId"`
-	CourseSubjectCode string `json:"courseSubjectCode"`
-	CourseName        string `json:"courseName"`
-	Credits           int    `json:"credits"`
-	Title             string `json:"title"`
+	CourseID          string         `json:"courseId"`
+	CourseSubjectCode string         `json:"courseSubjectCode"`
+	CourseName        string         `json:"courseName"`
+	Credits           int            `json:"credits"`
+	Title             string         `json:"title"`
+	ClassNumber       int            `json:"classNumber,omitempty"`
+	NotifyOn          []store.Status `json:"notifyOn,omitempty"`
+	MinOpenSeats      *int           `json:"minOpenSeats,omitempty"`
+	WaitlistBelow     *int           `json:"waitlistBelow,omitempty"`
 }
 
 // SubscriptionsResponse wraps the subscriptions array.
@@ -23,70 +27,52 @@ type SubscriptionsResponse struct {
 	Subscriptions []Subscription `json:"subscriptions"`
 }
 
+// Handler returns the authenticated caller's subscriptions. A userEmail
+// query parameter, as sent by older clients, is ignored. It authenticates the
+// caller itself, requiring the read:subscriptions scope, and applies the CORS
+// policy when deployed on its own, so that it behaves the same however it is
+// mounted.
 func Handler(w http.ResponseWriter, r *http.Request) {
-	// Set CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-	// Handle preflight
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
+	handler.ServeHTTP(w, r)
+}
 
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+var handler = standalone.Handler(
+	auth.RequireUser(auth.ScopeReadSubscriptions)(http.HandlerFunc(listSubscriptions)),
+	http.MethodGet,
+)
 
-	// Get the userEmail from the query parameters
-	userEmail := r.URL.Query().Get("userEmail")
-	if userEmail == "" {
-		http.Error(w, "userEmail query parameter is required", http.StatusBadRequest)
-		return
-	}
+func listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFrom(r.Context())
 
-	// Connect to the database using DATABASE_URL env variable
-	dbURL := os.Getenv("POSTGRES_URL")
-	pool, err := pgxpool.New(r.Context(), dbURL)
+	st, err := store.Default()
 	if err != nil {
 		http.Error(w, "Failed to connect to DB", http.StatusInternalServerError)
 		log.Println("DB connection error:", err)
 		return
 	}
-	defer pool.Close()
 
 	// Query subscriptions for the user
-	// Assuming your subscriptions table uses a composite unique key (user_id, course_id, course_subject_code)
-	query := `
-		SELECT course_id, course_subject_code, course_name, credits, title
-		FROM subscriptions
-		WHERE user_id = (SELECT id FROM users WHERE email = $1)
-	`
-	rows, err := pool.Query(r.Context(), query, userEmail)
+	subs, err := st.ListSubscriptions(r.Context(), user.Email)
 	if err != nil {
 		http.Error(w, "Failed to fetch subscriptions", http.StatusInternalServerError)
 		log.Println("DB query error:", err)
 		return
 	}
-	defer rows.Close()
 
-	var subscriptions []Subscription
-	for rows.Next() {
-		var sub Subscription
-		if err := rows.Scan(
-			&sub.CourseID,
-			&sub.CourseSubjectCode,
-			&sub.CourseName,
-			&sub.Credits,
-			&sub.Title,
-		); err != nil {
-			http.Error(w, "Failed to scan subscription", http.StatusInternalServerError)
-			log.Println("DB scan error:", err)
-			return
-		}
-		subscriptions = append(subscriptions, sub)
+	// An empty list, not null, when the caller has no subscriptions.
+	subscriptions := make([]Subscription, 0, len(subs))
+	for _, s := range subs {
+		subscriptions = append(subscriptions, Subscription{
+			CourseID:          s.CourseID,
+			CourseSubjectCode: s.CourseSubjectCode,
+			CourseName:        s.CourseName,
+			Credits:           s.Credits,
+			Title:             s.Title,
+			ClassNumber:       s.ClassNumber,
+			NotifyOn:          s.NotifyOn,
+			MinOpenSeats:      s.MinOpenSeats,
+			WaitlistBelow:     s.WaitlistBelow,
+		})
 	}
 
 	response := SubscriptionsResponse{
@@ -0,0 +1,32 @@
+package subscriptions
+
+import (
+	"backend/auth"
+	"backend/store"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListSubscriptionsReturnsEmptyList(t *testing.T) {
+	st := store.NewMemory()
+	store.SetDefault(st)
+	ctx := context.Background()
+	if err := st.UpsertUser(ctx, store.User{Email: "student@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := st.GetUser(ctx, "student@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/subscriptions", nil)
+	w := httptest.NewRecorder()
+	listSubscriptions(w, r.WithContext(auth.WithUser(r.Context(), user)))
+
+	if got := strings.TrimSpace(w.Body.String()); w.Code != http.StatusOK || got != `{"subscriptions":[]}` {
+		t.Errorf("listSubscriptions = %d %s, want an empty list", w.Code, got)
+	}
+}
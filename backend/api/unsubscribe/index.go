@@ -1,92 +1,62 @@
 package unsubscribe
 
 import (
+	"backend/auth"
+	"backend/server/standalone"
+	"backend/store"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // UnsubscribePayload defines the JSON structure for unsubscription.
+// Omitting classNumber removes every subscription to the course. Only the
+// authenticated caller's own subscriptions are removed.
 type UnsubscribePayload struct {
-	UserEmail         string `json:"userEmail"`
 	CourseID          string `json:"courseId"`
 	CourseSubjectCode string `json:"courseSubjectCode"`
+	ClassNumber       int    `json:"classNumber,omitempty"`
 }
 
+// Handler removes a course or section subscription of the authenticated
+// caller. It authenticates the caller itself, requiring the
+// write:subscriptions scope, and applies the CORS policy when deployed on its
+// own, so that it behaves the same however it is mounted.
 func Handler(w http.ResponseWriter, r *http.Request) {
-	// Set CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
+	handler.ServeHTTP(w, r)
+}
 
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+var handler = standalone.Handler(
+	auth.RequireUser(auth.ScopeWriteSubscriptions)(http.HandlerFunc(unsubscribe)),
+	http.MethodPost,
+)
 
+func unsubscribe(w http.ResponseWriter, r *http.Request) {
 	var payload UnsubscribePayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Received unsubscribe payload: %+v\n", payload)
+	user, _ := auth.UserFrom(r.Context())
+	log.Printf("Received unsubscribe payload from user %d: %+v\n", user.ID, payload)
 
-	dbURL := os.Getenv("POSTGRES_URL")
-	pool, err := pgxpool.New(r.Context(), dbURL)
+	st, err := store.Default()
 	if err != nil {
 		http.Error(w, "Failed to connect to DB", http.StatusInternalServerError)
 		log.Println("DB connection error:", err)
 		return
 	}
-	defer pool.Close()
 
-	// Delete the subscription record for this user and course.
-	deleteQuery := `
-		DELETE FROM subscriptions
-		WHERE user_id = (SELECT id FROM users WHERE email=$1)
-		  AND course_id = $2
-		  AND course_subject_code = $3
-	`
-	_, err = pool.Exec(r.Context(), deleteQuery, payload.UserEmail, payload.CourseID, payload.CourseSubjectCode)
+	// Delete the subscription records for this user and course. The store also
+	// drops the availability records if no subscriptions remain.
+	err = st.RemoveSubscription(r.Context(), user.Email, payload.CourseID, payload.CourseSubjectCode, payload.ClassNumber)
 	if err != nil {
 		http.Error(w, "Failed to unsubscribe", http.StatusInternalServerError)
 		log.Println("DB delete error:", err)
 		return
 	}
 
-	// Now check if any subscriptions remain for this course.
-	cleanupQuery := `
-		SELECT COUNT(*) 
-		FROM subscriptions 
-		WHERE course_id = $1 AND course_subject_code = $2
-	`
-	var count int
-	err = pool.QueryRow(r.Context(), cleanupQuery, payload.CourseID, payload.CourseSubjectCode).Scan(&count)
-	if err != nil {
-		log.Printf("Error checking remaining subscriptions for course %s: %v\n", payload.CourseID, err)
-	} else if count == 0 {
-		// No remaining subscriptions, so delete the course_availability record.
-		deleteAvailabilityQuery := `
-			DELETE FROM course_availability 
-			WHERE course_id = $1 AND course_subject_code = $2
-		`
-		_, err = pool.Exec(r.Context(), deleteAvailabilityQuery, payload.CourseID, payload.CourseSubjectCode)
-		if err != nil {
-			log.Printf("Error deleting course_availability for course %s: %v\n", payload.CourseID, err)
-		} else {
-			log.Printf("Deleted course_availability record for course %s as no subscriptions remain.\n", payload.CourseID)
-		}
-	}
-
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Unsubscription successful"))
 }
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"slices"
+	"strings"
+)
+
+// Scopes limit what a caller may do. Callers signed in with Google hold every
+// scope; an API key holds only those it was issued with.
+const (
+	ScopeReadSubscriptions  = "read:subscriptions"
+	ScopeWriteSubscriptions = "write:subscriptions"
+
+	// ScopeManageKeys covers issuing, listing and revoking API keys. It is
+	// never granted to a key, so keys cannot be used to mint more keys.
+	ScopeManageKeys = "manage:keys"
+)
+
+// KeyScopes are the scopes an API key may be issued with.
+var KeyScopes = []string{ScopeReadSubscriptions, ScopeWriteSubscriptions}
+
+const (
+	// apiKeyPrefix marks a bearer token as an API key rather than an ID token.
+	apiKeyPrefix = "bct_"
+
+	// apiKeyPrefixLen is how much of a key is kept in the clear for display.
+	apiKeyPrefixLen = len(apiKeyPrefix) + 6
+)
+
+// NewAPIKey generates a random API key. It returns the key, to be shown to
+// the user once, along with the prefix and hash to store in its place.
+func NewAPIKey() (key, prefix string, hash []byte, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", nil, err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:apiKeyPrefixLen], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up under. Keys
+// are long and random, so a fast hash is enough to make a leaked table useless.
+func HashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// isAPIKey reports whether a bearer token looks like an API key.
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// ValidKeyScopes reports whether scopes is a non-empty list of KeyScopes.
+func ValidKeyScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, s := range scopes {
+		if !slices.Contains(KeyScopes, s) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+// Package auth authenticates requests to the API: scheduled calls to the
+// cron endpoint as well as calls made on behalf of users.
+package auth
+
+import (
+	"backend/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by CronVerifier.Verify. Each is safe to show to the caller.
+var (
+	ErrCronNotConfigured  = errors.New("cron authentication is not configured")
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrStaleSignature     = errors.New("signature timestamp outside the allowed window")
+	ErrReplayedSignature  = errors.New("signature already used")
+)
+
+// Headers a cron caller may use instead of, or in addition to, a bearer token.
+const (
+	CronSecretHeader    = "X-Cron-Secret"
+	CronTimestampHeader = "X-Cron-Timestamp"
+	CronSignatureHeader = "X-Cron-Signature"
+)
+
+// defaultMaxSkew is how far a signed request's timestamp may be from now.
+const defaultMaxSkew = 5 * time.Minute
+
+// CronVerifier checks that a request to the cron endpoint comes from the
+// deployment's scheduler. A request is accepted if it carries the shared
+// Secret, either as "Authorization: Bearer <secret>" (as Vercel Cron sends it)
+// or in the X-Cron-Secret header, or if it is signed with SigningKey.
+//
+// A signed request sends the Unix time in X-Cron-Timestamp and, in
+// X-Cron-Signature, the hex HMAC-SHA256 of "<timestamp>\n<method>\n<path>"
+// under SigningKey, optionally prefixed with "sha256=". Each signature is
+// accepted once: the verifier remembers those it has seen until their
+// timestamp falls outside the allowed window. Replays are only caught by the
+// process that saw the original request.
+type CronVerifier struct {
+	Secret     string
+	SigningKey string
+	MaxSkew    time.Duration // defaults to five minutes
+
+	// Disabled accepts every request. It is meant for local development only.
+	Disabled bool
+
+	mu   sync.Mutex
+	seen map[string]time.Time // signature to when it stops being valid
+}
+
+// CronVerifierFromConfig configures a CronVerifier from cfg. Auth "none"
+// disables authentication.
+func CronVerifierFromConfig(cfg config.Cron) (*CronVerifier, error) {
+	v := &CronVerifier{
+		Secret:     cfg.Secret,
+		SigningKey: cfg.SigningKey,
+		MaxSkew:    cfg.MaxSkew,
+	}
+	switch cfg.Auth {
+	case "":
+	case "none":
+		v.Disabled = true
+	default:
+		return nil, fmt.Errorf("unknown CRON_AUTH %q", cfg.Auth)
+	}
+	return v, nil
+}
+
+// Verify returns nil if r is an authenticated cron request. A verifier with
+// neither a secret nor a signing key rejects everything unless disabled.
+func (v *CronVerifier) Verify(r *http.Request) error {
+	if v.Disabled {
+		return nil
+	}
+	if v.Secret == "" && v.SigningKey == "" {
+		return ErrCronNotConfigured
+	}
+
+	if v.Secret != "" {
+		if token, ok := cronSecret(r); ok {
+			if secretsEqual(token, v.Secret) {
+				return nil
+			}
+			return ErrInvalidCredentials
+		}
+	}
+	if v.SigningKey != "" && r.Header.Get(CronSignatureHeader) != "" {
+		return v.verifySignature(r)
+	}
+	return ErrMissingCredentials
+}
+
+func (v *CronVerifier) verifySignature(r *http.Request) error {
+	timestamp := r.Header.Get(CronTimestampHeader)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+	maxSkew := v.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxSkew
+	}
+	if skew := time.Since(time.Unix(sec, 0)); skew > maxSkew || skew < -maxSkew {
+		return ErrStaleSignature
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(CronSignatureHeader), "sha256="))
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+	if !hmac.Equal(got, SignCron(v.SigningKey, timestamp, r.Method, r.URL.Path)) {
+		return ErrInvalidCredentials
+	}
+	if !v.firstUse(hex.EncodeToString(got), time.Unix(sec, 0).Add(maxSkew)) {
+		return ErrReplayedSignature
+	}
+	return nil
+}
+
+// firstUse records a signature valid until expires, and reports whether it
+// had not been seen before. Expired signatures are forgotten.
+func (v *CronVerifier) firstUse(signature string, expires time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	for sig, exp := range v.seen {
+		if now.After(exp) {
+			delete(v.seen, sig)
+		}
+	}
+	if _, ok := v.seen[signature]; ok {
+		return false
+	}
+	if v.seen == nil {
+		v.seen = make(map[string]time.Time)
+	}
+	v.seen[signature] = expires
+	return true
+}
+
+// SignCron returns the HMAC-SHA256 signature of a cron request, for callers
+// that sign their requests instead of sending the shared secret.
+func SignCron(key, timestamp, method, path string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%s\n%s\n%s", timestamp, method, path)
+	return mac.Sum(nil)
+}
+
+// cronSecret returns the secret presented by r, if any.
+func cronSecret(r *http.Request) (string, bool) {
+	if token, ok := BearerToken(r); ok {
+		return token, true
+	}
+	if s := r.Header.Get(CronSecretHeader); s != "" {
+		return s, true
+	}
+	return "", false
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// secretsEqual compares two secrets in constant time. Hashing first keeps the
+// comparison from leaking the length of the expected secret.
+func secretsEqual(got, want string) bool {
+	g := sha256.Sum256([]byte(got))
+	w := sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(g[:], w[:]) == 1
+}
+
+var (
+	defaultMu   sync.Mutex
+	defaultCron *CronVerifier
+)
+
+// SetDefaultCron installs v as the CronVerifier returned by DefaultCron.
+func SetDefaultCron(v *CronVerifier) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCron = v
+}
+
+// DefaultCron returns the process-wide CronVerifier, building it with
+// CronVerifierFromConfig on first use unless one has been installed.
+func DefaultCron() (*CronVerifier, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultCron == nil {
+		cfg, err := config.Default()
+		if err != nil {
+			return nil, err
+		}
+		v, err := CronVerifierFromConfig(cfg.Cron)
+		if err != nil {
+			return nil, err
+		}
+		defaultCron = v
+	}
+	return defaultCron, nil
+}
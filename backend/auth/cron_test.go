@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const cronPath = "/api/cron/check-availability"
+
+// signedCronRequest returns a cron request signed with key at time at.
+func signedCronRequest(key string, at time.Time) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	r := httptest.NewRequest(http.MethodGet, cronPath, nil)
+	r.Header.Set(CronTimestampHeader, timestamp)
+	r.Header.Set(CronSignatureHeader, "sha256="+hex.EncodeToString(SignCron(key, timestamp, http.MethodGet, cronPath)))
+	return r
+}
+
+func TestCronVerifierSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		want    error
+	}{
+		{"valid", func() *http.Request {
+			return signedCronRequest("key", time.Now())
+		}, nil},
+		{"wrong key", func() *http.Request {
+			return signedCronRequest("other-key", time.Now())
+		}, ErrInvalidCredentials},
+		{"other path", func() *http.Request {
+			r := signedCronRequest("key", time.Now())
+			r.URL.Path = "/api/other"
+			return r
+		}, ErrInvalidCredentials},
+		{"malformed signature", func() *http.Request {
+			r := signedCronRequest("key", time.Now())
+			r.Header.Set(CronSignatureHeader, "sha256=not-hex")
+			return r
+		}, ErrInvalidCredentials},
+		{"malformed timestamp", func() *http.Request {
+			r := signedCronRequest("key", time.Now())
+			r.Header.Set(CronTimestampHeader, "yesterday")
+			return r
+		}, ErrInvalidCredentials},
+		{"too old", func() *http.Request {
+			return signedCronRequest("key", time.Now().Add(-10*time.Minute))
+		}, ErrStaleSignature},
+		{"too far ahead", func() *http.Request {
+			return signedCronRequest("key", time.Now().Add(10*time.Minute))
+		}, ErrStaleSignature},
+		{"unsigned", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, cronPath, nil)
+		}, ErrMissingCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &CronVerifier{SigningKey: "key", MaxSkew: 5 * time.Minute}
+			if err := v.Verify(tt.request()); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronVerifierRejectsReplayedSignature(t *testing.T) {
+	v := &CronVerifier{SigningKey: "key"}
+	now := time.Now()
+
+	if err := v.Verify(signedCronRequest("key", now)); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if err := v.Verify(signedCronRequest("key", now)); !errors.Is(err, ErrReplayedSignature) {
+		t.Errorf("replayed request: Verify = %v, want ErrReplayedSignature", err)
+	}
+	if err := v.Verify(signedCronRequest("key", now.Add(time.Second))); err != nil {
+		t.Errorf("request with a new timestamp: %v", err)
+	}
+}
+
+func TestCronVerifierSecret(t *testing.T) {
+	v := &CronVerifier{Secret: "secret"}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   error
+	}{
+		{"bearer", "Authorization", "Bearer secret", nil},
+		{"header", CronSecretHeader, "secret", nil},
+		{"wrong bearer", "Authorization", "Bearer guess", ErrInvalidCredentials},
+		{"wrong header", CronSecretHeader, "guess", ErrInvalidCredentials},
+		{"missing", "", "", ErrMissingCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, cronPath, nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			if err := v.Verify(r); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronVerifierUnconfigured(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, cronPath, nil)
+	r.Header.Set("Authorization", "Bearer anything")
+
+	if err := (&CronVerifier{}).Verify(r); !errors.Is(err, ErrCronNotConfigured) {
+		t.Errorf("Verify = %v, want ErrCronNotConfigured", err)
+	}
+	if err := (&CronVerifier{Disabled: true}).Verify(r); err != nil {
+		t.Errorf("disabled verifier: Verify = %v, want nil", err)
+	}
+}
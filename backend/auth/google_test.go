@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client.apps.googleusercontent.com"
+
+// testSigner signs ID tokens with a key generated for the test, which the
+// verifier trusts through StaticKeys.
+type testSigner struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+func newTestVerifier(t *testing.T) (*GoogleVerifier, testSigner) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := testSigner{kid: "test-key", key: key}
+
+	v := NewGoogleVerifier(testClientID)
+	v.Keys = StaticKeys{signer.kid: &key.PublicKey}
+	return v, signer
+}
+
+// validClaims are the claims of a token the verifier should accept.
+func validClaims() googleClaims {
+	now := time.Now()
+	return googleClaims{
+		Email:         "student@example.com",
+		EmailVerified: true,
+		Name:          "Student",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://accounts.google.com",
+			Subject:   "1234567890",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func (s testSigner) sign(t *testing.T, claims googleClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	raw, err := token.SignedString(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestGoogleVerifierAcceptsValidToken(t *testing.T) {
+	v, signer := newTestVerifier(t)
+
+	id, err := v.Verify(context.Background(), signer.sign(t, validClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	want := Identity{Subject: "1234567890", Email: "student@example.com", Name: "Student"}
+	if id != want {
+		t.Errorf("Verify = %+v, want %+v", id, want)
+	}
+}
+
+func TestGoogleVerifierRejectsInvalidTokens(t *testing.T) {
+	v, signer := newTestVerifier(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"bad audience", func() string {
+			claims := validClaims()
+			claims.Audience = jwt.ClaimStrings{"someone-else.apps.googleusercontent.com"}
+			return signer.sign(t, claims)
+		}},
+		{"bad issuer", func() string {
+			claims := validClaims()
+			claims.Issuer = "https://evil.example.com"
+			return signer.sign(t, claims)
+		}},
+		{"expired", func() string {
+			claims := validClaims()
+			claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+			return signer.sign(t, claims)
+		}},
+		{"missing expiry", func() string {
+			claims := validClaims()
+			claims.ExpiresAt = nil
+			return signer.sign(t, claims)
+		}},
+		{"unknown kid", func() string {
+			return testSigner{kid: "other-key", key: signer.key}.sign(t, validClaims())
+		}},
+		{"bad signature", func() string {
+			return testSigner{kid: signer.kid, key: otherKey}.sign(t, validClaims())
+		}},
+		{"unverified email", func() string {
+			claims := validClaims()
+			claims.EmailVerified = false
+			return signer.sign(t, claims)
+		}},
+		{"unsigned", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims())
+			token.Header["kid"] = signer.kid
+			raw, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return raw
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token())
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestGoogleVerifierUnknownKidWrapsErrUnknownKey(t *testing.T) {
+	v, signer := newTestVerifier(t)
+
+	raw := testSigner{kid: "other-key", key: signer.key}.sign(t, validClaims())
+	if _, err := v.Verify(context.Background(), raw); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Verify error = %v, want ErrUnknownKey", err)
+	}
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey is returned by a KeySource that has no key with the given ID.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySource looks up the public key that signed a token by its key ID.
+type KeySource interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// StaticKeys is a fixed set of public keys by key ID, for tests that sign
+// tokens with a locally generated key.
+type StaticKeys map[string]crypto.PublicKey
+
+func (s StaticKeys) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if k, ok := s[kid]; ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+const (
+	// defaultJWKSMaxAge applies when the key set response has no max-age.
+	defaultJWKSMaxAge = time.Hour
+
+	// minJWKSRefresh limits refetches, whether triggered by unknown key IDs
+	// or retrying a failed fetch, so that tokens with made-up IDs or an
+	// unreachable endpoint cannot make every request wait on the network.
+	minJWKSRefresh = time.Minute
+
+	// maxJWKSStale is how long past their max-age cached keys are still
+	// used while the key set cannot be fetched.
+	maxJWKSStale = 6 * time.Hour
+)
+
+// JWKS is a KeySource backed by a JSON Web Key Set fetched over HTTP. Keys
+// are cached for as long as the response's Cache-Control max-age allows, and
+// refetched early when a token names a key that is not in the cache, which
+// is how rotated keys are picked up. If a refetch fails, the cached keys are
+// served for up to maxJWKSStale longer.
+type JWKS struct {
+	URL    string
+	Client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	expires     time.Time
+	attemptedAt time.Time     // start of the last fetch, successful or not
+	fetchErr    error         // why the last fetch failed, or nil
+	refreshing  chan struct{} // closed when the fetch in flight finishes
+}
+
+// NewJWKS returns a JWKS that fetches keys from url.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	for {
+		if k, ok := j.keys[kid]; ok && time.Now().Before(j.expires) {
+			j.mu.Unlock()
+			return k, nil
+		}
+		if j.refreshing == nil {
+			break
+		}
+		// Another request is fetching the key set; use its result.
+		done := j.refreshing
+		j.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		j.mu.Lock()
+	}
+	if time.Since(j.attemptedAt) < minJWKSRefresh {
+		defer j.mu.Unlock()
+		return j.cached(kid)
+	}
+
+	// Fetch without holding the lock, so that requests for cached keys are
+	// not held up by a slow key endpoint.
+	done := make(chan struct{})
+	j.refreshing = done
+	j.attemptedAt = time.Now()
+	j.mu.Unlock()
+
+	// The fetch is shared with any request that waits for it, so it must not
+	// be cut short by this request going away; Client.Timeout bounds it.
+	keys, expires, err := j.fetch(context.WithoutCancel(ctx))
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err == nil {
+		j.keys, j.expires = keys, expires
+	}
+	j.fetchErr = err
+	j.refreshing = nil
+	close(done)
+	return j.cached(kid)
+}
+
+// cached returns the key with ID kid from the last key set fetched, even if
+// it has expired, as long as it is within maxJWKSStale of its max-age. j.mu
+// must be held.
+func (j *JWKS) cached(kid string) (crypto.PublicKey, error) {
+	if k, ok := j.keys[kid]; ok && time.Now().Before(j.expires.Add(maxJWKSStale)) {
+		return k, nil
+	}
+	if j.fetchErr != nil {
+		return nil, fmt.Errorf("fetch signing keys: %w", j.fetchErr)
+	}
+	return nil, ErrUnknownKey
+}
+
+// fetch returns the keys currently published at j.URL, and until when they
+// may be cached.
+func (j *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.URL, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, err := j.Client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("key set request failed with status: %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, time.Time{}, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		k, err := jwk.publicKey()
+		if err != nil {
+			// Skip key types we cannot use rather than rejecting the set.
+			continue
+		}
+		keys[jwk.Kid] = k
+	}
+	return keys, time.Now().Add(maxAge(resp.Header.Get("Cache-Control"))), nil
+}
+
+// maxAge returns the max-age directive of a Cache-Control header.
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(name, "max-age") {
+			if sec, err := strconv.Atoi(value); err == nil && sec > 0 {
+				return time.Duration(sec) * time.Second
+			}
+		}
+	}
+	return defaultJWKSMaxAge
+}
+
+// jsonWebKey is one entry of a key set (RFC 7517). Only RSA and P-256 EC
+// signing keys are supported.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key %s is not a signing key", k.Kid)
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
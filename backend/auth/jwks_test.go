@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keyServer publishes a key set over HTTP and counts the requests for it.
+type keyServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PublicKey
+	status   int
+	block    chan struct{} // if set, requests wait for it to be closed
+	requests int
+}
+
+func newKeyServer(t *testing.T) *keyServer {
+	t.Helper()
+	s := &keyServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *keyServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	block, status := s.block, s.status
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	for kid, k := range s.keys {
+		set.Keys = append(set.Keys, jsonWebKey{
+			Kid: kid,
+			Kty: "RSA",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		})
+	}
+	s.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	json.NewEncoder(w).Encode(set)
+}
+
+// publish replaces the published keys with a freshly generated key for each
+// of kids, and returns them.
+func (s *keyServer) publish(t *testing.T, kids ...string) map[string]*rsa.PublicKey {
+	t.Helper()
+	keys := make(map[string]*rsa.PublicKey)
+	for _, kid := range kids {
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[kid] = &k.PublicKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	return keys
+}
+
+func (s *keyServer) fail(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *keyServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// age moves the cache of j and its last fetch d into the past.
+func age(j *JWKS, d time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.expires = j.expires.Add(-d)
+	j.attemptedAt = j.attemptedAt.Add(-d)
+}
+
+func wantKey(t *testing.T, j *JWKS, kid string, want *rsa.PublicKey) {
+	t.Helper()
+	k, err := j.Key(context.Background(), kid)
+	if err != nil {
+		t.Fatalf("Key(%q): %v", kid, err)
+	}
+	if !want.Equal(k) {
+		t.Fatalf("Key(%q) returned a different key", kid)
+	}
+}
+
+func TestJWKSFetchesAndCachesKeys(t *testing.T) {
+	srv := newKeyServer(t)
+	keys := srv.publish(t, "a", "b")
+	j := NewJWKS(srv.URL)
+
+	wantKey(t, j, "a", keys["a"])
+	wantKey(t, j, "b", keys["b"])
+	if n := srv.count(); n != 1 {
+		t.Errorf("fetched the key set %d times, want 1", n)
+	}
+
+	// An unknown key ID is not refetched straight away.
+	if _, err := j.Key(context.Background(), "c"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(c) = %v, want ErrUnknownKey", err)
+	}
+	if n := srv.count(); n != 1 {
+		t.Errorf("unknown key ID fetched the key set again (%d fetches)", n)
+	}
+
+	// Once the cache expires, the key set is fetched again.
+	age(j, 2*time.Hour)
+	wantKey(t, j, "a", keys["a"])
+	if n := srv.count(); n != 2 {
+		t.Errorf("fetched the key set %d times after expiry, want 2", n)
+	}
+}
+
+func TestJWKSPicksUpRotatedKeys(t *testing.T) {
+	srv := newKeyServer(t)
+	old := srv.publish(t, "old")
+	j := NewJWKS(srv.URL)
+	wantKey(t, j, "old", old["old"])
+
+	rotated := srv.publish(t, "new")
+	age(j, minJWKSRefresh)
+	wantKey(t, j, "new", rotated["new"])
+	if n := srv.count(); n != 2 {
+		t.Errorf("fetched the key set %d times, want 2", n)
+	}
+	if _, err := j.Key(context.Background(), "old"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(old) after rotation = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestJWKSServesCachedKeysWhenFetchFails(t *testing.T) {
+	srv := newKeyServer(t)
+	keys := srv.publish(t, "a")
+	j := NewJWKS(srv.URL)
+	wantKey(t, j, "a", keys["a"])
+
+	srv.fail(http.StatusServiceUnavailable)
+	age(j, 2*time.Hour)
+	wantKey(t, j, "a", keys["a"])
+	if n := srv.count(); n != 2 {
+		t.Fatalf("fetched the key set %d times, want 2", n)
+	}
+
+	// The failed attempt counts against the refetch limit.
+	wantKey(t, j, "a", keys["a"])
+	if _, err := j.Key(context.Background(), "b"); err == nil || errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(b) after a failed fetch = %v, want the fetch error", err)
+	}
+	if n := srv.count(); n != 2 {
+		t.Errorf("retried the failed fetch straight away (%d fetches)", n)
+	}
+
+	// Past the grace period, the stale keys are no longer used.
+	age(j, maxJWKSStale)
+	if _, err := j.Key(context.Background(), "a"); err == nil {
+		t.Error("Key(a) served a key past the grace period")
+	}
+	if n := srv.count(); n != 3 {
+		t.Errorf("fetched the key set %d times, want 3", n)
+	}
+}
+
+func TestJWKSFetchDoesNotBlockCachedKeys(t *testing.T) {
+	srv := newKeyServer(t)
+	keys := srv.publish(t, "a")
+	j := NewJWKS(srv.URL)
+	wantKey(t, j, "a", keys["a"])
+
+	// A token with a new key ID starts a fetch that hangs.
+	block := make(chan struct{})
+	srv.mu.Lock()
+	srv.block = block
+	srv.mu.Unlock()
+	j.mu.Lock()
+	j.attemptedAt = j.attemptedAt.Add(-minJWKSRefresh)
+	j.mu.Unlock()
+
+	fetched := make(chan error, 1)
+	go func() {
+		_, err := j.Key(context.Background(), "new")
+		fetched <- err
+	}()
+	for srv.count() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Cached keys are served meanwhile, and requests waiting on the fetch
+	// give up with their context.
+	wantKey(t, j, "a", keys["a"])
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := j.Key(ctx, "new"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Key(new) during the fetch = %v, want the context's error", err)
+	}
+
+	close(block)
+	if err := <-fetched; !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key(new) = %v, want ErrUnknownKey", err)
+	}
+	if n := srv.count(); n != 2 {
+		t.Errorf("fetched the key set %d times, want 2", n)
+	}
+}
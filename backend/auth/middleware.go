@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"backend/store"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+)
+
+// ErrNotRegistered is returned when a verified caller has no user record.
+var ErrNotRegistered = errors.New("user is not registered")
+
+// principal is the authenticated caller of a request.
+type principal struct {
+	user   store.User
+	scopes []string // nil for a signed-in user, who holds every scope
+}
+
+func (p principal) has(scope string) bool {
+	return p.scopes == nil || slices.Contains(p.scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithUser returns a copy of ctx carrying u as a signed-in user.
+func WithUser(ctx context.Context, u store.User) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal{user: u})
+}
+
+// UserFrom returns the user that RequireUser authenticated for this request.
+func UserFrom(ctx context.Context) (store.User, bool) {
+	p, ok := ctx.Value(principalKey{}).(principal)
+	return p.user, ok
+}
+
+// RequireUser returns middleware that only lets requests from a registered
+// user holding scope through, with that user in the request context. The
+// caller is identified by the bearer token in the Authorization header, which
+// is either a Google ID token or one of the user's API keys; any user identity
+// in the request itself is ignored by handlers behind it. Preflight requests
+// carry no credentials and are passed through as they are.
+func RequireUser(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return requireUser(scope, next)
+	}
+}
+
+func requireUser(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := authenticate(r)
+		switch {
+		case err == nil && p.has(scope):
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+		case err == nil:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+			http.Error(w, "API key lacks scope "+scope, http.StatusForbidden)
+		case errors.Is(err, ErrMissingCredentials):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+		case errors.Is(err, ErrInvalidToken):
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Invalid bearer token", http.StatusUnauthorized)
+			log.Println("Rejected token:", err)
+		case errors.Is(err, ErrNotRegistered):
+			http.Error(w, "User is not registered", http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to authenticate", http.StatusInternalServerError)
+			log.Println("Auth error:", err)
+		}
+	})
+}
+
+// authenticate resolves the registered user making r, and what they may do.
+func authenticate(r *http.Request) (principal, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return principal{}, ErrMissingCredentials
+	}
+
+	st, err := store.Default()
+	if err != nil {
+		return principal{}, fmt.Errorf("connect to DB: %w", err)
+	}
+
+	if isAPIKey(token) {
+		key, user, err := st.UseAPIKey(r.Context(), HashAPIKey(token))
+		if errors.Is(err, store.ErrNotFound) {
+			return principal{}, fmt.Errorf("%w: unknown or revoked API key", ErrInvalidToken)
+		}
+		if err != nil {
+			return principal{}, err
+		}
+		// A key never holds every scope, even if it was stored without any.
+		scopes := key.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		return principal{user: user, scopes: scopes}, nil
+	}
+
+	verifier, err := DefaultGoogle()
+	if err != nil {
+		return principal{}, fmt.Errorf("configure token verification: %w", err)
+	}
+	id, err := verifier.Verify(r.Context(), token)
+	if err != nil {
+		return principal{}, err
+	}
+	user, err := st.GetUser(r.Context(), id.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		return principal{}, ErrNotRegistered
+	}
+	if err != nil {
+		return principal{}, err
+	}
+	return principal{user: user}, nil
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"backend/store"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newMiddlewareStore installs an in-memory store with student@example.com
+// registered, and returns it with the user.
+func newMiddlewareStore(t *testing.T) (*store.Memory, store.User) {
+	t.Helper()
+
+	st := store.NewMemory()
+	store.SetDefault(st)
+	ctx := context.Background()
+	if err := st.UpsertUser(ctx, store.User{Email: "student@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := st.GetUser(ctx, "student@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st, user
+}
+
+// issueKey stores a new API key for user with the given scopes and returns
+// the raw key.
+func issueKey(t *testing.T, st store.Store, user store.User, scopes ...string) string {
+	t.Helper()
+
+	key, prefix, hash, err := NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = st.CreateAPIKey(context.Background(), store.APIKey{UserID: user.ID, Prefix: prefix, Hash: hash, Scopes: scopes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// serveAs runs a request with the given bearer token through RequireUser
+// and returns the response along with the user it let through, if any.
+func serveAs(scope, bearer string, r *http.Request) (*httptest.ResponseRecorder, *store.User) {
+	var seen *store.User
+	h := RequireUser(scope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, ok := UserFrom(r.Context()); ok {
+			seen = &u
+		}
+	}))
+	if bearer != "" {
+		r.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w, seen
+}
+
+func TestRequireUserEnforcesKeyScopes(t *testing.T) {
+	st, user := newMiddlewareStore(t)
+	readOnly := issueKey(t, st, user, ScopeReadSubscriptions)
+	readWrite := issueKey(t, st, user, ScopeReadSubscriptions, ScopeWriteSubscriptions)
+
+	// The scope /api/subscribe requires.
+	w, seen := serveAs(ScopeWriteSubscriptions, readOnly, httptest.NewRequest(http.MethodPost, "/api/subscribe", nil))
+	if w.Code != http.StatusForbidden || seen != nil {
+		t.Errorf("read-only key on subscribe: status %d, want 403", w.Code)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); !strings.Contains(got, "insufficient_scope") {
+		t.Errorf("WWW-Authenticate = %q, want insufficient_scope", got)
+	}
+
+	w, seen = serveAs(ScopeWriteSubscriptions, readWrite, httptest.NewRequest(http.MethodPost, "/api/subscribe", nil))
+	if w.Code != http.StatusOK || seen == nil || seen.ID != user.ID {
+		t.Errorf("read-write key on subscribe: status %d, user %v; want the key's user let through", w.Code, seen)
+	}
+
+	w, _ = serveAs(ScopeReadSubscriptions, readOnly, httptest.NewRequest(http.MethodGet, "/api/subscriptions", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("read-only key on subscriptions: status %d, want 200", w.Code)
+	}
+}
+
+func TestRequireUserRejectsUnknownAndRevokedKeys(t *testing.T) {
+	st, user := newMiddlewareStore(t)
+	key := issueKey(t, st, user, ScopeReadSubscriptions)
+
+	unknown, _, _, err := NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, _ := serveAs(ScopeReadSubscriptions, unknown, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: status %d, want 401", w.Code)
+	}
+
+	keys, err := st.ListAPIKeys(context.Background(), user.ID)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("ListAPIKeys = %v, %v", keys, err)
+	}
+	if err := st.RevokeAPIKey(context.Background(), user.ID, keys[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if w, _ := serveAs(ScopeReadSubscriptions, key, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked key: status %d, want 401", w.Code)
+	}
+}
+
+func TestRequireUserNeverGrantsManageKeysToKeys(t *testing.T) {
+	st, user := newMiddlewareStore(t)
+	key := issueKey(t, st, user, KeyScopes...)
+
+	if w, _ := serveAs(ScopeManageKeys, key, httptest.NewRequest(http.MethodPost, "/api/keys", nil)); w.Code != http.StatusForbidden {
+		t.Errorf("key on /api/keys: status %d, want 403", w.Code)
+	}
+
+	// Not even a key stored without scopes.
+	unscoped := issueKey(t, st, user)
+	if w, _ := serveAs(ScopeManageKeys, unscoped, httptest.NewRequest(http.MethodPost, "/api/keys", nil)); w.Code != http.StatusForbidden {
+		t.Errorf("unscoped key on /api/keys: status %d, want 403", w.Code)
+	}
+}
+
+// signIn installs a verifier trusting a test key and returns a valid ID token
+// for email.
+func signIn(t *testing.T, email string) string {
+	t.Helper()
+
+	v, signer := newTestVerifier(t)
+	SetDefaultGoogle(v)
+	claims := validClaims()
+	claims.Email = email
+	return signer.sign(t, claims)
+}
+
+func TestRequireUserRejectsMissingOrMalformedBearer(t *testing.T) {
+	newMiddlewareStore(t)
+	token := signIn(t, "student@example.com")
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"missing", ""},
+		{"not bearer", "Basic c3R1ZGVudDpzZWNyZXQ="},
+		{"empty bearer", "Bearer "},
+		{"not a token", "Bearer not-a-jwt"},
+		{"tampered", "Bearer " + token[:len(token)-4] + "AAAA"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/subscriptions", nil)
+		if tt.authorization != "" {
+			r.Header.Set("Authorization", tt.authorization)
+		}
+		w, seen := serveAs(ScopeReadSubscriptions, "", r)
+		if w.Code != http.StatusUnauthorized || seen != nil {
+			t.Errorf("%s: status %d, want 401", tt.name, w.Code)
+		}
+		if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Bearer") {
+			t.Errorf("%s: WWW-Authenticate = %q, want a Bearer challenge", tt.name, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestRequireUserRejectsUnregisteredUser(t *testing.T) {
+	newMiddlewareStore(t)
+	token := signIn(t, "stranger@example.com")
+
+	w, seen := serveAs(ScopeReadSubscriptions, token, httptest.NewRequest(http.MethodGet, "/api/subscriptions", nil))
+	if w.Code != http.StatusForbidden || seen != nil {
+		t.Errorf("unregistered user: status %d, want 403", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "not registered") {
+		t.Errorf("body = %q, want it to say the user is not registered", body)
+	}
+}
+
+func TestRequireUserIgnoresUserEmailInRequest(t *testing.T) {
+	st, user := newMiddlewareStore(t)
+	if err := st.UpsertUser(context.Background(), store.User{Email: "victim@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	token := signIn(t, user.Email)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/subscribe?userEmail=victim@example.com",
+		strings.NewReader(`{"userEmail":"victim@example.com","courseId":"024798"}`))
+	w, seen := serveAs(ScopeWriteSubscriptions, token, r)
+	if w.Code != http.StatusOK || seen == nil {
+		t.Fatalf("status %d, want the signed-in user let through", w.Code)
+	}
+	if seen.Email != user.Email || seen.ID != user.ID {
+		t.Errorf("request ran as %s, want the token's %s", seen.Email, user.Email)
+	}
+}
@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"backend/enroll"
+	"backend/store"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// batchSize is the number of courses asked about in a single search request.
+const batchSize = 100
+
+// prescreen narrows the watched courses down to those that need a full
+// per-section check, using two searches per subject (per batchSize courses)
+// instead of one request per course. A course needs checking if it has an
+// open or waitlisted section now, or had one at the last check, or if it is
+// no longer found in the term and has not yet been recorded as not offered.
+// Courses that were and still are closed keep their recorded state until a
+// search reports them enrollable again.
+//
+// Should the searches fail, or return courses that were not asked about,
+// every course is checked instead, so that a change in the search API costs
+// requests rather than missed notifications.
+func prescreen(ctx context.Context, st store.Store, courses []store.WatchedCourse, termCode string) ([]store.WatchedCourse, error) {
+	offered, enrollable, err := searchCourses(ctx, courses, termCode)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("Batch prescreen failed, checking every course: %v\n", err)
+		return courses, nil
+	}
+
+	var toCheck []store.WatchedCourse
+	for _, c := range courses {
+		key := courseKeyOf(c)
+		if enrollable[key] {
+			toCheck = append(toCheck, c)
+			continue
+		}
+
+		prev, err := st.GetCourseAvailability(ctx, c.CourseID, c.CourseSubjectCode)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			// Never checked: a closed course keeps the default closed state,
+			// but one missing from the term is recorded as not offered.
+			if !offered[key] {
+				toCheck = append(toCheck, c)
+			}
+		case err != nil:
+			return nil, err
+		case prev.Status == store.StatusOpen || prev.Status == store.StatusWaitlisted:
+			toCheck = append(toCheck, c)
+		case !offered[key] && prev.Status != store.StatusNotOffered:
+			// Withdrawn since the last check; the section check records it.
+			toCheck = append(toCheck, c)
+		case offered[key] && prev.Status == store.StatusNotOffered:
+			// Reinstated, if only as closed; the section check records it.
+			toCheck = append(toCheck, c)
+		}
+	}
+
+	log.Printf("Batch prescreen: %d of %d courses need a section check\n", len(toCheck), len(courses))
+	return toCheck, nil
+}
+
+type courseKey struct {
+	courseID          string
+	courseSubjectCode string
+}
+
+func courseKeyOf(c store.WatchedCourse) courseKey {
+	return courseKey{c.CourseID, c.CourseSubjectCode}
+}
+
+// searchCourses returns the set of courses that are offered in the term,
+// and the subset of those with at least one open or waitlisted enrollment
+// package.
+func searchCourses(ctx context.Context, courses []store.WatchedCourse, termCode string) (offered, enrollable map[courseKey]bool, err error) {
+	bySubject := make(map[string][]string)
+	var subjects []string
+	for _, c := range courses {
+		if _, ok := bySubject[c.CourseSubjectCode]; !ok {
+			subjects = append(subjects, c.CourseSubjectCode)
+		}
+		bySubject[c.CourseSubjectCode] = append(bySubject[c.CourseSubjectCode], c.CourseID)
+	}
+
+	enrollableFilter := enroll.PackageFilter(
+		enroll.MatchField("packageEnrollmentStatus.status", "OPEN WAITLISTED"),
+		enroll.MatchField("published", true),
+	)
+
+	offered = make(map[courseKey]bool)
+	enrollable = make(map[courseKey]bool)
+	for _, subject := range subjects {
+		ids := bySubject[subject]
+		for start := 0; start < len(ids); start += batchSize {
+			batch := ids[start:min(start+batchSize, len(ids))]
+			if err := searchBatch(ctx, termCode, subject, batch, offered); err != nil {
+				return nil, nil, err
+			}
+			if err := searchBatch(ctx, termCode, subject, batch, enrollable, enrollableFilter); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return offered, enrollable, nil
+}
+
+// searchBatch pages through the courses among ids in one subject that match
+// filters, and records them in found. Hits are matched on course ID and
+// subject code, never on their designation. Finding more courses than were
+// asked about means the search ignored the subject or course ID filter.
+func searchBatch(ctx context.Context, termCode, subjectCode string, ids []string, found map[courseKey]bool, filters ...enroll.Filter) error {
+	filters = append([]enroll.Filter{
+		enroll.SubjectFilter(subjectCode),
+		enroll.CourseIDsFilter(ids...),
+	}, filters...)
+
+	for page := 1; ; page++ {
+		result, err := enroll.Default().Search(ctx, enroll.SearchRequest{
+			SelectedTerm: termCode,
+			QueryString:  "*",
+			Filters:      filters,
+			Page:         page,
+			PageSize:     batchSize,
+			SortOrder:    enroll.SortScore,
+		})
+		if err != nil {
+			return err
+		}
+		if result.Found > len(ids) {
+			return fmt.Errorf("search for %d courses in subject %s found %d", len(ids), subjectCode, result.Found)
+		}
+
+		for _, hit := range result.Hits {
+			if hit.Subject.SubjectCode == subjectCode && slices.Contains(ids, hit.CourseID) {
+				found[courseKey{hit.CourseID, subjectCode}] = true
+			}
+		}
+		if len(result.Hits) == 0 || page*batchSize >= result.Found {
+			return nil
+		}
+	}
+}
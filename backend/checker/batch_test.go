@@ -0,0 +1,120 @@
+package checker
+
+import (
+	"backend/config"
+	"backend/enroll"
+	"backend/enroll/enrolltest"
+	"backend/store"
+	"context"
+	"testing"
+)
+
+func newBatchTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	e := newTestEnv(t)
+	cfg, err := config.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Check.Mode = "batch"
+	return e
+}
+
+func TestBatchSkipsCoursesThatStayClosed(t *testing.T) {
+	e := newBatchTestEnv(t)
+	e.api.AddCourse(testCourse, []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "CLOSED", 0, 0),
+	})
+	e.subscribe(t, store.Subscription{})
+
+	e.run(t)
+	before := e.api.Requests()
+	e.run(t)
+
+	// Two searches, and no enrollment packages request.
+	if n := e.api.Requests() - before; n != 2 {
+		t.Errorf("second run made %d requests, want 2", n)
+	}
+}
+
+func TestBatchRecordsWithdrawnClosedCourse(t *testing.T) {
+	e := newBatchTestEnv(t)
+	e.api.AddCourse(testCourse, []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "OPEN", 3, 0),
+	}, []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "CLOSED", 0, 0),
+	})
+	e.subscribe(t, store.Subscription{})
+
+	e.run(t)
+	e.api.Advance()
+	e.run(t)
+
+	ctx := context.Background()
+	got, err := e.st.GetCourseAvailability(ctx, testCourse.CourseID, testCourse.Subject.SubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != store.StatusClosed {
+		t.Fatalf("course recorded as %s, want closed", got.Status)
+	}
+
+	e.api.RemoveCourse(testCourse.Subject.SubjectCode, testCourse.CourseID)
+	e.run(t)
+
+	got, err = e.st.GetCourseAvailability(ctx, testCourse.CourseID, testCourse.Subject.SubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != store.StatusNotOffered {
+		t.Errorf("withdrawn course recorded as %s, want not_offered", got.Status)
+	}
+
+	// Once recorded, a withdrawn course is not fetched again.
+	before := e.api.Requests()
+	e.run(t)
+	if n := e.api.Requests() - before; n != 2 {
+		t.Errorf("run after withdrawal made %d requests, want 2", n)
+	}
+}
+
+func TestBatchRecordsReinstatedClosedCourse(t *testing.T) {
+	e := newBatchTestEnv(t)
+	closed := []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "CLOSED", 0, 0),
+	}
+	e.api.AddCourse(testCourse, closed)
+	e.subscribe(t, store.Subscription{})
+	e.run(t)
+
+	e.api.RemoveCourse(testCourse.Subject.SubjectCode, testCourse.CourseID)
+	e.run(t)
+
+	ctx := context.Background()
+	got, err := e.st.GetCourseAvailability(ctx, testCourse.CourseID, testCourse.Subject.SubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != store.StatusNotOffered {
+		t.Fatalf("withdrawn course recorded as %s, want not_offered", got.Status)
+	}
+
+	// The course comes back, still closed.
+	e.api.AddCourse(testCourse, closed)
+	e.run(t)
+
+	got, err = e.st.GetCourseAvailability(ctx, testCourse.CourseID, testCourse.Subject.SubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != store.StatusClosed {
+		t.Errorf("reinstated course recorded as %s, want closed", got.Status)
+	}
+
+	// Once recorded as closed, it is skipped again.
+	before := e.api.Requests()
+	e.run(t)
+	if n := e.api.Requests() - before; n != 2 {
+		t.Errorf("run after reinstatement made %d requests, want 2", n)
+	}
+}
@@ -0,0 +1,473 @@
+// Package checker polls the enroll API for every watched course, records
+// course- and section-level availability, and notifies subscribers about changes.
+package checker
+
+import (
+	"backend/config"
+	"backend/enroll"
+	"backend/metrics"
+	"backend/notify"
+	"backend/store"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// snapshot is the observed state of a course or section that subscription
+// conditions are evaluated against.
+type snapshot struct {
+	status       store.Status
+	openSeats    int
+	waitlistSize int
+}
+
+// unseen is assumed for a course or section that has never been checked.
+var unseen = snapshot{status: store.StatusClosed}
+
+// ErrRunInProgress is returned by Run when another run, in this or any other
+// process sharing the store, has not finished yet.
+var ErrRunInProgress = errors.New("checker: a run is already in progress")
+
+// errLeaseLost cancels a run whose lease could not be renewed.
+var errLeaseLost = errors.New("checker: run lease lost")
+
+// Runs are serialized through leases in the store, so that overlapping cron
+// invocations, the scheduler and other instances never check the same course
+// at once. The run lease is renewed while the run lasts, and the run is
+// cancelled if it cannot be; should two runs overlap anyway, the per-course
+// leases and RecordCheck's version check still keep a change from being
+// notified twice.
+const (
+	runLease    = "check-availability"
+	runLeaseTTL = 15 * time.Minute
+
+	courseLeaseTTL = 2 * time.Minute
+)
+
+// Run checks every watched course once, using a bounded pool of workers. It
+// stops handing out courses when ctx is cancelled. With CHECK_MODE=batch,
+// courses are first prescreened with a few batched searches so that only
+// those that may have changed are fetched section by section.
+func Run(ctx context.Context) (err error) {
+	st, err := store.Default()
+	if err != nil {
+		return fmt.Errorf("connect to DB: %w", err)
+	}
+	notifier, err := notify.Default()
+	if err != nil {
+		return fmt.Errorf("configure notifier: %w", err)
+	}
+
+	holder := newHolder()
+	ok, err := st.AcquireLease(ctx, runLease, holder, runLeaseTTL)
+	if err != nil {
+		return fmt.Errorf("acquire run lease: %w", err)
+	}
+	if !ok {
+		return ErrRunInProgress
+	}
+	defer releaseLease(ctx, st, runLease, holder)
+
+	// Stop renewing before the lease is released, so that a late renewal
+	// cannot take it again.
+	ctx, cancel := context.WithCancelCause(ctx)
+	renewing := make(chan struct{})
+	go func() {
+		defer close(renewing)
+		holdLease(ctx, st, runLease, holder, runLeaseTTL, cancel)
+	}()
+	defer func() {
+		cancel(nil)
+		<-renewing
+	}()
+
+	// Runs turned away by the lease above are not observed: they did no work.
+	start := time.Now()
+	var checked atomic.Int64
+	defer func() {
+		metrics.ObserveCheckRun(time.Since(start), int(checked.Load()), err)
+	}()
+
+	// Query distinct courses from subscriptions.
+	coursesToCheck, err := st.ListWatchedCourses(ctx)
+	if err != nil {
+		return fmt.Errorf("query subscriptions: %w", err)
+	}
+
+	cfg, err := config.Default()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	termCode, termShortDesc := cfg.Term.Code, cfg.Term.ShortDescription
+
+	if cfg.Check.Mode == "batch" {
+		coursesToCheck, err = prescreen(ctx, st, coursesToCheck, termCode)
+		if err != nil {
+			return fmt.Errorf("batch prescreen: %w", err)
+		}
+	}
+
+	concurrency := cfg.Check.Concurrency
+
+	jobs := make(chan store.WatchedCourse)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(coursesToCheck)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for course := range jobs {
+				err := checkCourse(ctx, st, course, termCode, termShortDesc, holder)
+				metrics.ObserveCourseCheck(err)
+				if err != nil {
+					log.Printf("Error checking %s: %v\n", course.CourseName, err)
+					continue
+				}
+				checked.Add(1)
+			}
+		}()
+	}
+
+feed:
+	for _, course := range coursesToCheck {
+		select {
+		case jobs <- course:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	// Deliver what this run queued, plus any earlier failures now due for retry.
+	result, err := notify.NewDispatcher(st, notifier).Dispatch(ctx)
+	if err != nil {
+		return fmt.Errorf("dispatch notifications: %w", err)
+	}
+	log.Printf("Notifications: %d sent, %d to retry, %d dead\n", result.Sent, result.Failed, result.Dead)
+	return nil
+}
+
+// checkCourse fetches a course's enrollment packages and, in one transaction,
+// stores the state of each section and of the course as a whole together with
+// the notifications owed to subscribers. Courses leased by another run are
+// skipped, as are checks that another run recorded first.
+func checkCourse(ctx context.Context, st store.Store, course store.WatchedCourse, termCode, termShortDesc, holder string) error {
+	lease := fmt.Sprintf("%s:%s/%s", runLease, course.CourseSubjectCode, course.CourseID)
+	ok, err := st.AcquireLease(ctx, lease, holder, courseLeaseTTL)
+	if err != nil {
+		return fmt.Errorf("acquire course lease: %w", err)
+	}
+	if !ok {
+		log.Printf("%s is being checked by another run, skipping\n", course.CourseName)
+		return nil
+	}
+	defer releaseLease(ctx, st, lease, holder)
+
+	// Packages are looked up by the stored course_id and course_subject_code,
+	// so a differently named or better-scoring course can never stand in.
+	packages, err := enroll.Default().EnrollmentPackages(ctx, termCode, course.CourseSubjectCode, course.CourseID)
+	if err != nil && !errors.Is(err, enroll.ErrNotFound) {
+		return err
+	}
+	packages = slices.DeleteFunc(packages, func(p enroll.EnrollmentPackage) bool {
+		return (p.CourseID != "" && p.CourseID != course.CourseID) ||
+			(p.SubjectCode != "" && p.SubjectCode != course.CourseSubjectCode)
+	})
+	if len(packages) == 0 {
+		// The course no longer appears in the term; record that explicitly
+		// as not offered rather than as closed.
+		log.Printf("%s (%s/%s) not found in term %s\n", course.CourseName, course.CourseSubjectCode, course.CourseID, termCode)
+	}
+
+	// Retrieve previous state from course_availability. It is read before the
+	// sections so that its version covers them: any write to the sections
+	// after this point also bumps the version RecordCheck compares against.
+	prevCourse := unseen
+	var version int64
+	if prev, err := st.GetCourseAvailability(ctx, course.CourseID, course.CourseSubjectCode); err == nil {
+		prevCourse = snapshot{prev.Status, prev.OpenSeats, prev.WaitlistSize}
+		version = prev.Version
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	prevSections, err := st.ListSectionAvailability(ctx, course.CourseID, course.CourseSubjectCode)
+	if err != nil {
+		return err
+	}
+	prevByClass := make(map[int]snapshot, len(prevSections))
+	for _, s := range prevSections {
+		prevByClass[s.ClassNumber] = snapshot{s.Status, s.OpenSeats, s.WaitlistSize}
+	}
+
+	sectionList := make([]store.SectionAvailability, 0, len(packages))
+	for _, p := range packages {
+		sectionList = append(sectionList, sectionFromPackage(course, p))
+	}
+	sectionList = append(sectionList, withdrawnSections(prevSections, sectionList)...)
+
+	sections := make(map[int]store.SectionAvailability, len(sectionList))
+	changed := false
+	var changedLines []string
+	var transitions [][2]store.Status
+	for _, section := range sectionList {
+		sections[section.ClassNumber] = section
+
+		prev, ok := prevByClass[section.ClassNumber]
+		if !ok {
+			prev = unseen
+		}
+		if prev != snapshotOf(section) {
+			changed = true
+		}
+		if prev.status != section.Status {
+			changedLines = append(changedLines, fmt.Sprintf("%s: %s → %s", section.Sections, prev.status, section.Status))
+			transitions = append(transitions, [2]store.Status{prev.status, section.Status})
+		}
+	}
+
+	current := courseSnapshot(sectionList)
+	courseAvailability := store.CourseAvailability{
+		CourseID:          course.CourseID,
+		CourseSubjectCode: course.CourseSubjectCode,
+		CourseName:        course.CourseName,
+		Status:            current.status,
+		OpenSeats:         current.openSeats,
+		WaitlistSize:      current.waitlistSize,
+		Version:           version,
+	}
+
+	var outbox []store.Notification
+	if changed || current != prevCourse {
+		subs, err := st.ListCourseSubscriptions(ctx, course.CourseID, course.CourseSubjectCode)
+		if err != nil {
+			return fmt.Errorf("fetch subscribers: %w", err)
+		}
+		check := courseCheck{
+			course:       course,
+			prev:         prevCourse,
+			current:      current,
+			prevSections: prevByClass,
+			sections:     sections,
+			changedLines: changedLines,
+		}
+		outbox = check.notifications(subs, termShortDesc)
+	}
+
+	// Upsert the centralized course availability record and queue the
+	// notifications together, so a change is never recorded without them.
+	err = st.RecordCheck(ctx, courseAvailability, sectionList, outbox)
+	if errors.Is(err, store.ErrConflict) {
+		// Another run recorded this course since it was read here, and has
+		// queued whatever notifications the change called for.
+		log.Printf("%s was recorded by another run, discarding this check\n", course.CourseName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if prevCourse.status != current.status {
+		metrics.ObserveTransition("course", string(prevCourse.status), string(current.status))
+	}
+	for _, t := range transitions {
+		metrics.ObserveTransition("section", string(t[0]), string(t[1]))
+	}
+	return nil
+}
+
+// newHolder returns a lease holder ID unique to one run.
+func newHolder() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// holdLease renews a lease every third of ttl until ctx is done. If another
+// holder has taken the lease, or it could not be renewed before expiring,
+// lost is called.
+func holdLease(ctx context.Context, st store.Store, name, holder string, ttl time.Duration, lost func(error)) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := st.AcquireLease(ctx, name, holder, ttl)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil && time.Since(renewed) < ttl:
+			log.Printf("Error renewing lease %s, retrying: %v\n", name, err)
+		case err != nil:
+			lost(fmt.Errorf("%w: %w", errLeaseLost, err))
+			return
+		case !ok:
+			lost(errLeaseLost)
+			return
+		default:
+			renewed = time.Now()
+		}
+	}
+}
+
+// releaseLease gives up a lease, even once ctx has been cancelled, so that the
+// next run need not wait for it to expire.
+func releaseLease(ctx context.Context, st store.Store, name, holder string) {
+	if err := st.ReleaseLease(context.WithoutCancel(ctx), name, holder); err != nil {
+		log.Printf("Error releasing lease %s: %v\n", name, err)
+	}
+}
+
+// courseCheck is the observed change in one course since its last check.
+type courseCheck struct {
+	course        store.WatchedCourse
+	prev, current snapshot
+	prevSections  map[int]snapshot
+	sections      map[int]store.SectionAvailability
+	changedLines  []string // human-readable section status changes
+}
+
+// notifications builds the outbox entries owed to subs for this change.
+func (c courseCheck) notifications(subs []store.Subscription, termShortDesc string) []store.Notification {
+	var outbox []store.Notification
+	for _, sub := range subs {
+		name := c.course.CourseName
+		prev, cur := c.prev, c.current
+		var details []string
+		if sub.ClassNumber == 0 {
+			// Whole-course subscribers hear which sections caused the change.
+			if len(c.changedLines) > 0 {
+				details = append([]string{"Sections that changed:"}, c.changedLines...)
+			}
+		} else {
+			section, ok := c.sections[sub.ClassNumber]
+			if !ok {
+				continue
+			}
+			if prev, ok = c.prevSections[sub.ClassNumber]; !ok {
+				prev = unseen
+			}
+			cur = snapshotOf(section)
+			name = fmt.Sprintf("%s (%s)", c.course.CourseName, section.Sections)
+		}
+
+		if !shouldNotify(sub, prev, cur) {
+			continue
+		}
+		details = append(details, fmt.Sprintf("Open seats: %d. Waitlist: %d.", cur.openSeats, cur.waitlistSize))
+
+		msg := courseUpdateMessage(sub.UserEmail, termShortDesc, name, prev.status, cur.status, details)
+		outbox = append(outbox, store.Notification{
+			Recipient:         msg.To,
+			Subject:           msg.Subject,
+			HTML:              msg.HTML,
+			CourseID:          c.course.CourseID,
+			CourseSubjectCode: c.course.CourseSubjectCode,
+		})
+	}
+	return outbox
+}
+
+// shouldNotify decides whether a subscriber hears about a change from prev to
+// cur. Subscriptions with seat or waitlist thresholds are notified when their
+// condition becomes true, or when the course or section is withdrawn; others
+// are notified when the status changes. Either way, the new status must be
+// one the subscriber asked for.
+func shouldNotify(sub store.Subscription, prev, cur snapshot) bool {
+	if !sub.Wants(cur.status) {
+		return false
+	}
+	if sub.MinOpenSeats == nil && sub.WaitlistBelow == nil ||
+		cur.status == store.StatusNotOffered {
+		return prev.status != cur.status
+	}
+	return !thresholdMet(sub, prev) && thresholdMet(sub, cur)
+}
+
+// thresholdMet reports whether s satisfies either of sub's threshold conditions.
+func thresholdMet(sub store.Subscription, s snapshot) bool {
+	if sub.MinOpenSeats != nil && s.status == store.StatusOpen && s.openSeats >= *sub.MinOpenSeats {
+		return true
+	}
+	if sub.WaitlistBelow != nil && s.status == store.StatusWaitlisted && s.waitlistSize < *sub.WaitlistBelow {
+		return true
+	}
+	return false
+}
+
+// courseSnapshot aggregates the sections of a course. A course takes the most
+// enrollable status among its sections, and is not offered when it has none.
+// Its open seats are the total across sections, and its waitlist the shortest
+// waitlist of a waitlisted section, which may be empty.
+func courseSnapshot(sections []store.SectionAvailability) snapshot {
+	current := snapshot{status: store.StatusNotOffered}
+	waitlisted := false
+	for _, section := range sections {
+		if section.Status.Rank() > current.status.Rank() {
+			current.status = section.Status
+		}
+		current.openSeats += section.OpenSeats
+		if section.Status == store.StatusWaitlisted &&
+			(!waitlisted || section.WaitlistSize < current.waitlistSize) {
+			current.waitlistSize = section.WaitlistSize
+			waitlisted = true
+		}
+	}
+	return current
+}
+
+// withdrawnSections returns the previously recorded sections that are missing
+// from current, marked as not offered with no seats, so that a withdrawn
+// section stops showing its last availability and its subscribers hear that
+// it is gone.
+func withdrawnSections(prev, current []store.SectionAvailability) []store.SectionAvailability {
+	var withdrawn []store.SectionAvailability
+	for _, section := range prev {
+		if slices.ContainsFunc(current, func(s store.SectionAvailability) bool {
+			return s.ClassNumber == section.ClassNumber
+		}) {
+			continue
+		}
+		section.Status = store.StatusNotOffered
+		section.OpenSeats, section.Capacity, section.WaitlistSize = 0, 0, 0
+		withdrawn = append(withdrawn, section)
+	}
+	return withdrawn
+}
+
+func snapshotOf(s store.SectionAvailability) snapshot {
+	return snapshot{s.Status, s.OpenSeats, s.WaitlistSize}
+}
+
+// sectionFromPackage converts an enrollment package to the stored section state.
+func sectionFromPackage(course store.WatchedCourse, p enroll.EnrollmentPackage) store.SectionAvailability {
+	return store.SectionAvailability{
+		CourseID:          course.CourseID,
+		CourseSubjectCode: course.CourseSubjectCode,
+		ClassNumber:       p.EnrollmentClassNumber,
+		Sections:          p.Label(),
+		Status:            store.ParseStatus(p.PackageEnrollmentStatus.Status),
+		OpenSeats:         p.EnrollmentStatus.OpenSeats,
+		Capacity:          p.EnrollmentStatus.Capacity,
+		WaitlistSize:      p.EnrollmentStatus.WaitlistCurrentSize,
+	}
+}
@@ -0,0 +1,252 @@
+package checker
+
+import (
+	"backend/config"
+	"backend/enroll"
+	"backend/enroll/enrolltest"
+	"backend/notify"
+	"backend/store"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recorder is a Notifier that keeps every message it is asked to send.
+type recorder struct {
+	mu   sync.Mutex
+	sent []notify.Message
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Send(ctx context.Context, msg notify.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (r *recorder) messages() []notify.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]notify.Message(nil), r.sent...)
+}
+
+// testEnv wires the checker's process-wide defaults to an in-memory store, a
+// fake enroll API and a recording notifier.
+type testEnv struct {
+	st   *store.Memory
+	api  *enrolltest.Server
+	mail *recorder
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	cfg := config.Defaults()
+	cfg.Store.Driver = "memory"
+	cfg.Notify.Provider = "log"
+	config.SetDefault(cfg)
+
+	api := enrolltest.NewServer()
+	t.Cleanup(api.Close)
+	enroll.SetDefault(api.Client())
+
+	st := store.NewMemory()
+	store.SetDefault(st)
+
+	mail := &recorder{}
+	notify.SetDefault(mail)
+
+	return &testEnv{st: st, api: api, mail: mail}
+}
+
+// testCourse is the course every test watches.
+var testCourse = enroll.Course{
+	TermCode: "1262",
+	CourseID: "024798",
+	Subject:  enroll.Subject{TermCode: "1262", SubjectCode: "266", ShortDescription: "COMP SCI"},
+	Title:    "Programming II",
+}
+
+func (e *testEnv) subscribe(t *testing.T, sub store.Subscription) {
+	t.Helper()
+	ctx := context.Background()
+
+	if sub.UserEmail == "" {
+		sub.UserEmail = "student@example.com"
+	}
+	if err := e.st.UpsertUser(ctx, store.User{Email: sub.UserEmail, Name: "Student"}); err != nil {
+		t.Fatal(err)
+	}
+	sub.CourseID = testCourse.CourseID
+	sub.CourseSubjectCode = testCourse.Subject.SubjectCode
+	sub.CourseName = "COMP SCI 300"
+	if err := e.st.AddSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (e *testEnv) run(t *testing.T) {
+	t.Helper()
+	if err := Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func ptr(n int) *int { return &n }
+
+func TestCourseSnapshot(t *testing.T) {
+	section := func(status store.Status, open, waitlist int) store.SectionAvailability {
+		return store.SectionAvailability{Status: status, OpenSeats: open, WaitlistSize: waitlist}
+	}
+
+	tests := []struct {
+		name     string
+		sections []store.SectionAvailability
+		want     snapshot
+	}{
+		{
+			name: "no sections",
+			want: snapshot{status: store.StatusNotOffered},
+		},
+		{
+			name: "empty waitlist first",
+			sections: []store.SectionAvailability{
+				section(store.StatusWaitlisted, 0, 0),
+				section(store.StatusWaitlisted, 0, 5),
+			},
+			want: snapshot{status: store.StatusWaitlisted},
+		},
+		{
+			name: "empty waitlist last",
+			sections: []store.SectionAvailability{
+				section(store.StatusWaitlisted, 0, 5),
+				section(store.StatusWaitlisted, 0, 0),
+			},
+			want: snapshot{status: store.StatusWaitlisted},
+		},
+		{
+			name: "shortest waitlist in the middle",
+			sections: []store.SectionAvailability{
+				section(store.StatusWaitlisted, 0, 7),
+				section(store.StatusWaitlisted, 0, 2),
+				section(store.StatusWaitlisted, 0, 4),
+			},
+			want: snapshot{status: store.StatusWaitlisted, waitlistSize: 2},
+		},
+		{
+			name: "closed sections do not count toward the waitlist",
+			sections: []store.SectionAvailability{
+				section(store.StatusClosed, 0, 0),
+				section(store.StatusWaitlisted, 0, 3),
+			},
+			want: snapshot{status: store.StatusWaitlisted, waitlistSize: 3},
+		},
+		{
+			name: "open seats are summed",
+			sections: []store.SectionAvailability{
+				section(store.StatusOpen, 2, 0),
+				section(store.StatusWaitlisted, 0, 6),
+				section(store.StatusOpen, 3, 0),
+			},
+			want: snapshot{status: store.StatusOpen, openSeats: 5, waitlistSize: 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := courseSnapshot(tt.sections); got != tt.want {
+				t.Errorf("courseSnapshot() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWaitlistThresholdIgnoresSectionOrder(t *testing.T) {
+	empty := enrolltest.Package(1001, "LEC", "001", "WAITLISTED", 0, 0)
+	long := enrolltest.Package(1002, "LEC", "002", "WAITLISTED", 0, 5)
+
+	orders := map[string][]enroll.EnrollmentPackage{
+		"empty first": {empty, long},
+		"empty last":  {long, empty},
+	}
+	for name, packages := range orders {
+		t.Run(name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.api.AddCourse(testCourse, packages)
+			e.subscribe(t, store.Subscription{WaitlistBelow: ptr(3)})
+
+			e.run(t)
+
+			got, err := e.st.GetCourseAvailability(context.Background(), testCourse.CourseID, testCourse.Subject.SubjectCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != store.StatusWaitlisted || got.WaitlistSize != 0 {
+				t.Errorf("course recorded as %s with waitlist %d, want waitlisted with 0", got.Status, got.WaitlistSize)
+			}
+			if n := len(e.mail.messages()); n != 1 {
+				t.Errorf("sent %d messages, want 1", n)
+			}
+		})
+	}
+}
+
+func TestRunWithdrawnCourseMarksSectionsNotOffered(t *testing.T) {
+	e := newTestEnv(t)
+	e.api.AddCourse(testCourse, []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "OPEN", 3, 0),
+	})
+	e.subscribe(t, store.Subscription{UserEmail: "course@example.com"})
+	e.subscribe(t, store.Subscription{UserEmail: "section@example.com", ClassNumber: 1001})
+	e.subscribe(t, store.Subscription{UserEmail: "seats@example.com", ClassNumber: 1001, MinOpenSeats: ptr(1)})
+
+	e.run(t)
+	if n := len(e.mail.messages()); n != 3 {
+		t.Fatalf("first run sent %d messages, want 3", n)
+	}
+
+	e.api.RemoveCourse(testCourse.Subject.SubjectCode, testCourse.CourseID)
+	e.run(t)
+
+	ctx := context.Background()
+	course, err := e.st.GetCourseAvailability(ctx, testCourse.CourseID, testCourse.Subject.SubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if course.Status != store.StatusNotOffered {
+		t.Errorf("course recorded as %s, want not_offered", course.Status)
+	}
+
+	sections, err := e.st.ListSectionAvailability(ctx, testCourse.CourseID, testCourse.Subject.SubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 1 || sections[0].Status != store.StatusNotOffered || sections[0].OpenSeats != 0 {
+		t.Errorf("sections recorded as %+v, want one not offered with no seats", sections)
+	}
+
+	var recipients []string
+	for _, msg := range e.mail.messages()[3:] {
+		recipients = append(recipients, msg.To)
+	}
+	slices.Sort(recipients)
+	want := []string{"course@example.com", "seats@example.com", "section@example.com"}
+	if !slices.Equal(recipients, want) {
+		t.Errorf("withdrawal notified %v, want %v", recipients, want)
+	}
+}
+
+func TestCourseUpdateMessageEscapesCourseName(t *testing.T) {
+	msg := courseUpdateMessage("student@example.com", "Spring 2026", `<a href="https://evil.example.com">COMP SCI 300</a>`,
+		store.StatusClosed, store.StatusOpen, []string{"LEC 001: closed → open"})
+
+	if strings.Contains(msg.HTML, "<a ") {
+		t.Errorf("course name was not escaped: %s", msg.HTML)
+	}
+	if !strings.Contains(msg.HTML, "&lt;a href=") {
+		t.Errorf("escaped course name missing: %s", msg.HTML)
+	}
+}
@@ -0,0 +1,30 @@
+package checker
+
+import (
+	"backend/notify"
+	"backend/store"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// courseUpdateMessage builds the email telling a subscriber that courseName
+// went from prevStatus to newStatus, followed by any detail lines.
+func courseUpdateMessage(recipientEmail, term, courseName string, prevStatus, newStatus store.Status, details []string) notify.Message {
+	// The course name comes from the subscriber, so everything but the
+	// fixed markup is escaped.
+	var detailsHTML string
+	if len(details) > 0 {
+		escaped := make([]string, len(details))
+		for i, line := range details {
+			escaped[i] = html.EscapeString(line)
+		}
+		detailsHTML = "<br><br>" + strings.Join(escaped, "<br>")
+	}
+	return notify.Message{
+		To:      recipientEmail,
+		Subject: fmt.Sprintf("Course Update: %s is now %s", courseName, newStatus),
+		HTML: fmt.Sprintf("<p>%s<br><br>%s was previously <strong>%s</strong>.<br>It is now <strong>%s</strong>.%s<br><br>Thank you.</p>",
+			html.EscapeString(term), html.EscapeString(courseName), prevStatus, newStatus, detailsHTML),
+	}
+}
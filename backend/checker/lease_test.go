@@ -0,0 +1,177 @@
+package checker
+
+import (
+	"backend/enroll"
+	"backend/enroll/enrolltest"
+	"backend/store"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRunSkipsWhileAnotherRunHoldsTheLease(t *testing.T) {
+	e := newTestEnv(t)
+	e.api.AddCourse(testCourse, []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "OPEN", 3, 0),
+	})
+	e.subscribe(t, store.Subscription{})
+
+	ctx := context.Background()
+	if ok, err := e.st.AcquireLease(ctx, runLease, "other-run", time.Minute); err != nil || !ok {
+		t.Fatalf("AcquireLease = %v, %v", ok, err)
+	}
+
+	if err := Run(ctx); !errors.Is(err, ErrRunInProgress) {
+		t.Fatalf("Run = %v, want ErrRunInProgress", err)
+	}
+	if n := e.api.Requests(); n != 0 {
+		t.Errorf("Run made %d requests while another run held the lease", n)
+	}
+
+	if err := e.st.ReleaseLease(ctx, runLease, "other-run"); err != nil {
+		t.Fatal(err)
+	}
+	e.run(t)
+	if n := len(e.mail.messages()); n != 1 {
+		t.Errorf("sent %d messages once the lease was free, want 1", n)
+	}
+}
+
+func TestRunSkipsCourseLeasedByAnotherRun(t *testing.T) {
+	e := newTestEnv(t)
+	e.api.AddCourse(testCourse, []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "OPEN", 3, 0),
+	})
+	e.subscribe(t, store.Subscription{})
+
+	ctx := context.Background()
+	lease := fmt.Sprintf("%s:%s/%s", runLease, testCourse.Subject.SubjectCode, testCourse.CourseID)
+	if ok, err := e.st.AcquireLease(ctx, lease, "other-run", time.Minute); err != nil || !ok {
+		t.Fatalf("AcquireLease = %v, %v", ok, err)
+	}
+
+	e.run(t)
+
+	if _, err := e.st.GetCourseAvailability(ctx, testCourse.CourseID, testCourse.Subject.SubjectCode); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("leased course was recorded (err = %v)", err)
+	}
+	if n := len(e.mail.messages()); n != 0 {
+		t.Errorf("sent %d messages for a leased course", n)
+	}
+}
+
+// racingStore lets another run record a course between checkCourse reading
+// its state and recording the new one.
+type racingStore struct {
+	*store.Memory
+	race func()
+}
+
+func (s racingStore) RecordCheck(ctx context.Context, course store.CourseAvailability, sections []store.SectionAvailability, outbox []store.Notification) error {
+	if s.race != nil {
+		s.race()
+	}
+	return s.Memory.RecordCheck(ctx, course, sections, outbox)
+}
+
+func TestCheckCourseDiscardsConflictingCheck(t *testing.T) {
+	e := newTestEnv(t)
+	e.api.AddCourse(testCourse, []enroll.EnrollmentPackage{
+		enrolltest.Package(1001, "LEC", "001", "OPEN", 3, 0),
+	})
+	e.subscribe(t, store.Subscription{})
+
+	ctx := context.Background()
+	course := store.WatchedCourse{
+		CourseID:          testCourse.CourseID,
+		CourseSubjectCode: testCourse.Subject.SubjectCode,
+		CourseName:        "COMP SCI 300",
+	}
+	st := racingStore{Memory: e.st, race: func() {
+		// The other run records the course first.
+		err := e.st.RecordCheck(ctx, store.CourseAvailability{
+			CourseID:          course.CourseID,
+			CourseSubjectCode: course.CourseSubjectCode,
+			CourseName:        course.CourseName,
+			Status:            store.StatusOpen,
+			OpenSeats:         3,
+		}, nil, nil)
+		if err != nil {
+			t.Errorf("competing RecordCheck: %v", err)
+		}
+	}}
+
+	if err := checkCourse(ctx, st, course, "1262", "Spring 2026", "this-run"); err != nil {
+		t.Fatalf("checkCourse = %v, want the conflict to be absorbed", err)
+	}
+
+	claimed, err := e.st.ClaimNotifications(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("discarded check queued %d notifications", len(claimed))
+	}
+	sections, err := e.st.ListSectionAvailability(ctx, course.CourseID, course.CourseSubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 0 {
+		t.Errorf("discarded check recorded %d sections", len(sections))
+	}
+}
+
+func TestHoldLeaseRenewsUntilCancelled(t *testing.T) {
+	st := store.NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const ttl = 60 * time.Millisecond
+	if ok, _ := st.AcquireLease(ctx, "lease", "holder", ttl); !ok {
+		t.Fatal("AcquireLease failed")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		holdLease(ctx, st, "lease", "holder", ttl, func(err error) {
+			t.Errorf("lease reported lost: %v", err)
+		})
+	}()
+
+	time.Sleep(3 * ttl)
+	if ok, _ := st.AcquireLease(ctx, "lease", "other", ttl); ok {
+		t.Error("lease expired while being renewed")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestHoldLeaseReportsLostLease(t *testing.T) {
+	st := store.NewMemory()
+	ctx := context.Background()
+
+	const ttl = 60 * time.Millisecond
+	if ok, _ := st.AcquireLease(ctx, "lease", "holder", ttl); !ok {
+		t.Fatal("AcquireLease failed")
+	}
+	// Another holder takes over, as if this one had stalled past the TTL.
+	st.ReleaseLease(ctx, "lease", "holder")
+	if ok, _ := st.AcquireLease(ctx, "lease", "other", time.Minute); !ok {
+		t.Fatal("AcquireLease by other failed")
+	}
+
+	lost := make(chan error, 1)
+	go holdLease(ctx, st, "lease", "holder", ttl, func(err error) { lost <- err })
+
+	select {
+	case err := <-lost:
+		if !errors.Is(err, errLeaseLost) {
+			t.Errorf("lost called with %v, want errLeaseLost", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lost lease was not reported")
+	}
+}
@@ -0,0 +1,46 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// Schedule runs a check immediately and then repeatedly, waiting interval
+// plus a random delay of up to jitter after each run finishes, until stop is
+// closed or ctx is cancelled. Because the wait starts when a run ends, runs
+// never overlap. A run that is in progress when stop is closed is allowed to
+// finish, while one in progress when ctx is cancelled is cancelled too.
+func Schedule(ctx context.Context, stop <-chan struct{}, interval, jitter time.Duration) {
+	log.Printf("Scheduling availability checks every %s (jitter %s)\n", interval, jitter)
+	for {
+		start := time.Now()
+		err := Run(ctx)
+		switch {
+		case errors.Is(err, ErrRunInProgress):
+			log.Println("Skipping scheduled check: another run is in progress")
+		case err != nil && ctx.Err() == nil:
+			log.Println("Scheduled check error:", err)
+		case err == nil:
+			log.Printf("Scheduled check completed in %s\n", time.Since(start).Round(time.Millisecond))
+		}
+
+		wait := interval
+		if jitter > 0 {
+			wait += rand.N(jitter)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop:
+		case <-ctx.Done():
+		case <-timer.C:
+			continue
+		}
+		timer.Stop()
+		log.Println("Scheduler stopped")
+		return
+	}
+}
@@ -0,0 +1,44 @@
+// Command fake-enroll serves canned enroll search API responses so the
+// backend and cron can run without the real university API:
+//
+//	go run ./cmd/fake-enroll -addr :8001 -advance 1m
+//	ENROLL_API_URL=http://localhost:8001 go run .
+package main
+
+import (
+	"backend/enroll/enrolltest"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8001", "address to listen on")
+	fixtures := flag.String("fixtures", "", "JSON fixtures file (defaults to the built-in catalogue)")
+	advance := flag.Duration("advance", 0, "advance every course to its next scripted step at this interval (0 disables)")
+	flag.Parse()
+
+	f, err := enrolltest.DefaultFixtures()
+	if *fixtures != "" {
+		f, err = enrolltest.LoadFixturesFile(*fixtures)
+	}
+	if err != nil {
+		log.Fatal("Failed to load fixtures:", err)
+	}
+
+	api := enrolltest.NewHandler()
+	api.LoadFixtures(f)
+
+	if *advance > 0 {
+		go func() {
+			for range time.Tick(*advance) {
+				api.Advance()
+				log.Println("Advanced scripted courses to the next step")
+			}
+		}()
+	}
+
+	log.Printf("Fake enroll API serving %d courses on http://localhost%s", len(f.Courses), *addr)
+	log.Fatal(http.ListenAndServe(*addr, api))
+}
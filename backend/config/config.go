@@ -0,0 +1,252 @@
+// Package config defines every setting the backend reads, loads them from
+// the environment, .env.local and an optional YAML or TOML file, and checks
+// them before the server starts.
+package config
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is the backend's complete configuration. Each setting has an
+// environment variable, named in its env tag, and a key in the config file,
+// named in its yaml and toml tags under its section.
+type Config struct {
+	// Port is the address the server listens on, such as ":8000" or "8000".
+	Port string `yaml:"port" toml:"port" env:"PORT"`
+
+	Server Server `yaml:"server" toml:"server"`
+	Term   Term   `yaml:"term" toml:"term"`
+	Store  Store  `yaml:"store" toml:"store"`
+	Enroll Enroll `yaml:"enroll" toml:"enroll"`
+	Check  Check  `yaml:"check" toml:"check"`
+	Notify Notify `yaml:"notify" toml:"notify"`
+	Google Google `yaml:"google" toml:"google"`
+	Cron   Cron   `yaml:"cron" toml:"cron"`
+	CORS   CORS   `yaml:"cors" toml:"cors"`
+	Health Health `yaml:"health" toml:"health"`
+}
+
+// Server configures the HTTP server. WriteTimeout bounds ordinary requests;
+// the cron endpoint extends its own deadline. ShutdownTimeout is how long
+// in-flight requests and a running check get to finish once the server is
+// asked to stop.
+type Server struct {
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// Term is the academic term whose courses are searched and tracked.
+type Term struct {
+	Code             string `yaml:"code" toml:"code" env:"TERM_CODE"`
+	ShortDescription string `yaml:"short_description" toml:"short_description" env:"TERM_SHORT_DESCRIPTION"`
+}
+
+// Store selects the persistence layer: "postgres" at PostgresURL, or
+// "memory" for an in-process store.
+type Store struct {
+	Driver      string `yaml:"driver" toml:"driver" env:"STORE"`
+	PostgresURL string `yaml:"postgres_url" toml:"postgres_url" env:"POSTGRES_URL"`
+}
+
+// Enroll configures the client for the UW enroll API. An empty APIURL uses
+// the public API.
+type Enroll struct {
+	APIURL    string  `yaml:"api_url" toml:"api_url" env:"ENROLL_API_URL"`
+	RateLimit float64 `yaml:"rate_limit" toml:"rate_limit" env:"ENROLL_RATE_LIMIT"`
+	RateBurst int     `yaml:"rate_burst" toml:"rate_burst" env:"ENROLL_RATE_BURST"`
+}
+
+// Check tunes availability checks. A zero Interval leaves scheduling to an
+// external cron; Mode "batch" prescreens courses with batched searches, and
+// "course" (the default) fetches every course's sections.
+type Check struct {
+	Interval    time.Duration `yaml:"interval" toml:"interval" env:"CHECK_INTERVAL"`
+	Jitter      time.Duration `yaml:"jitter" toml:"jitter" env:"CHECK_JITTER"`
+	Concurrency int           `yaml:"concurrency" toml:"concurrency" env:"CHECK_CONCURRENCY"`
+	Mode        string        `yaml:"mode" toml:"mode" env:"CHECK_MODE"`
+}
+
+// Notify selects the email provider: "smtp", "mailgun", "mailersend" or
+// "log", and holds the settings of each. See Selected for what an empty
+// Provider means.
+type Notify struct {
+	Provider   string     `yaml:"provider" toml:"provider" env:"NOTIFIER"`
+	SMTP       SMTP       `yaml:"smtp" toml:"smtp"`
+	Gmail      Gmail      `yaml:"gmail" toml:"gmail"`
+	Mailgun    Mailgun    `yaml:"mailgun" toml:"mailgun"`
+	MailerSend MailerSend `yaml:"mailersend" toml:"mailersend"`
+}
+
+// Selected returns the provider in use. An empty Provider selects "smtp" if
+// an SMTP server or Gmail account is configured, and none otherwise: logging
+// notifications instead of sending them has to be asked for with "log".
+func (n Notify) Selected() string {
+	if n.Provider != "" {
+		return n.Provider
+	}
+	if n.SMTP.Host != "" || n.Gmail.Email != "" {
+		return "smtp"
+	}
+	return ""
+}
+
+// localFrontend is where the frontend's development server listens.
+const localFrontend = "http://localhost:3000"
+
+// Origins returns the origins allowed to call the API: AllowedOrigins if set,
+// and otherwise the origin of FrontendURL, or of the local frontend when that
+// is unset too.
+func (c CORS) Origins() []string {
+	if len(c.AllowedOrigins) > 0 {
+		return c.AllowedOrigins
+	}
+	frontend := c.FrontendURL
+	if frontend == "" {
+		frontend = localFrontend
+	}
+	if u, err := url.Parse(frontend); err == nil && u.Scheme != "" && u.Host != "" {
+		return []string{u.Scheme + "://" + u.Host}
+	}
+	return []string{strings.TrimSuffix(frontend, "/")}
+}
+
+// SMTP is a generic SMTP server using PLAIN auth over STARTTLS.
+type SMTP struct {
+	Host     string `yaml:"host" toml:"host" env:"SMTP_HOST"`
+	Port     string `yaml:"port" toml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" toml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" toml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" toml:"from" env:"SMTP_FROM"`
+}
+
+// Gmail is the original way of configuring SMTP, through smtp.gmail.com with
+// an app password. It is used when SMTP.Host is unset.
+type Gmail struct {
+	Email    string `yaml:"email" toml:"email" env:"GMAIL_SMTP_EMAIL"`
+	Password string `yaml:"password" toml:"password" env:"GMAIL_SMTP_PASS"`
+}
+
+// Mailgun configures the Mailgun HTTP API. APIBase selects a non-US region.
+type Mailgun struct {
+	Domain  string `yaml:"domain" toml:"domain" env:"MAILGUN_DOMAIN"`
+	APIKey  string `yaml:"api_key" toml:"api_key" env:"MAILGUN_API_KEY"`
+	From    string `yaml:"from" toml:"from" env:"MAILGUN_FROM"`
+	APIBase string `yaml:"api_base" toml:"api_base" env:"MAILGUN_API_BASE"`
+}
+
+// MailerSend configures the MailerSend HTTP API.
+type MailerSend struct {
+	APIKey string `yaml:"api_key" toml:"api_key" env:"MAILERSEND_API_KEY"`
+	From   string `yaml:"from" toml:"from" env:"MAILERSEND_FROM"`
+}
+
+// Google configures verification of Google ID tokens. ClientIDs are the
+// OAuth clients tokens may be issued to; JWKSURL replaces Google's key set
+// for local development.
+type Google struct {
+	ClientIDs []string `yaml:"client_ids" toml:"client_ids" env:"GOOGLE_CLIENT_ID"`
+	JWKSURL   string   `yaml:"jwks_url" toml:"jwks_url" env:"GOOGLE_JWKS_URL"`
+}
+
+// Cron configures authentication of the cron endpoint. Auth "none" turns it
+// off; otherwise Secret, SigningKey or both must be set.
+type Cron struct {
+	Auth       string        `yaml:"auth" toml:"auth" env:"CRON_AUTH"`
+	Secret     string        `yaml:"secret" toml:"secret" env:"CRON_SECRET"`
+	SigningKey string        `yaml:"signing_key" toml:"signing_key" env:"CRON_SIGNING_KEY"`
+	MaxSkew    time.Duration `yaml:"max_skew" toml:"max_skew" env:"CRON_MAX_SKEW"`
+}
+
+// CORS is the policy for browser calls from other origins. Unless
+// AllowedOrigins is set, only the frontend at FrontendURL is allowed.
+type CORS struct {
+	FrontendURL      string        `yaml:"frontend_url" toml:"frontend_url" env:"FRONTEND_URL"`
+	AllowedOrigins   []string      `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// Health configures the readiness check. ProbeUpstreams adds the enroll API
+// and, where it can be checked, the email provider to the database; each
+// check must finish within Timeout.
+type Health struct {
+	ProbeUpstreams bool          `yaml:"probe_upstreams" toml:"probe_upstreams" env:"READY_PROBE_UPSTREAMS"`
+	Timeout        time.Duration `yaml:"timeout" toml:"timeout" env:"READY_TIMEOUT"`
+}
+
+// Defaults returns the settings used where neither the environment nor the
+// config file says otherwise.
+func Defaults() *Config {
+	return &Config{
+		Port: ":8000",
+		Server: Server{
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Term: Term{
+			Code:             "1262",
+			ShortDescription: "Term 1262",
+		},
+		Store:  Store{Driver: "postgres"},
+		Enroll: Enroll{RateLimit: 5, RateBurst: 1},
+		Check:  Check{Concurrency: 8, Mode: "course"},
+		Cron:   Cron{MaxSkew: 5 * time.Minute},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Authorization"},
+			MaxAge:         10 * time.Minute,
+		},
+		Health: Health{Timeout: 2 * time.Second},
+	}
+}
+
+// Addr returns Port as a listen address, accepting a bare port number as
+// hosting platforms usually set it.
+func (c *Config) Addr() string {
+	if strings.Contains(c.Port, ":") {
+		return c.Port
+	}
+	return ":" + c.Port
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultConfig *Config
+)
+
+// SetDefault installs c as the Config returned by Default. main.go calls it
+// once at startup with the configuration it has validated.
+func SetDefault(c *Config) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultConfig = c
+}
+
+// Default returns the process-wide Config. If none has been installed, it is
+// loaded with Load from CONFIG_FILE and validated on first use, so serverless
+// invocations see the same settings as the server.
+func Default() (*Config, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultConfig == nil {
+		c, err := Load(FileFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+		defaultConfig = c
+	}
+	return defaultConfig, nil
+}
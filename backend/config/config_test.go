@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": "term:\n  code: \"1264\"\ncheck:\n  concurrency: 4\n  mode: batch\n",
+		"config.toml": "[term]\ncode = \"1264\"\n\n[check]\nconcurrency = 4\nmode = \"batch\"\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			// Empty variables are ignored, so these cannot leak in from the
+			// environment the tests run in.
+			t.Setenv("TERM_CODE", "")
+			t.Setenv("CHECK_MODE", "")
+			t.Setenv("ENROLL_RATE_LIMIT", "")
+			t.Setenv("CHECK_CONCURRENCY", "2")
+
+			c, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Check.Concurrency != 2 {
+				t.Errorf("Check.Concurrency = %d, want the environment's 2", c.Check.Concurrency)
+			}
+			if c.Term.Code != "1264" || c.Check.Mode != "batch" {
+				t.Errorf("Term.Code = %q, Check.Mode = %q; want the file's 1264 and batch", c.Term.Code, c.Check.Mode)
+			}
+			if c.Enroll.RateLimit != 5 || c.Server.ReadTimeout != 15*time.Second {
+				t.Errorf("Enroll.RateLimit = %v, Server.ReadTimeout = %s; want the defaults", c.Enroll.RateLimit, c.Server.ReadTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidEnvironment(t *testing.T) {
+	t.Setenv("CHECK_INTERVAL", "often")
+
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "CHECK_INTERVAL") {
+		t.Errorf("Load = %v, want an error naming CHECK_INTERVAL", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := Defaults()
+	c.Term.Code = ""
+	c.Notify.Provider = "mailgun"
+	c.Notify.Mailgun.Domain = "mg.example.com"
+	c.Check.Mode = "sometimes"
+
+	var verr *ValidationError
+	if err := c.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("Validate = %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"TERM_CODE is required",
+		"POSTGRES_URL is required",
+		`CHECK_MODE "sometimes" is not one of course, batch`,
+		"MAILGUN_API_KEY is required",
+		"MAILGUN_FROM is required",
+	}
+	if !slices.Equal(verr.Problems, want) {
+		t.Errorf("problems = %q, want %q", verr.Problems, want)
+	}
+}
+
+func TestValidateRequiresNotifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*Notify)
+		want  string // a problem Validate must report, or "" for none
+	}{
+		{"unset", func(n *Notify) {}, "NOTIFIER is required"},
+		{"log", func(n *Notify) { n.Provider = "log" }, ""},
+		{"smtp by host", func(n *Notify) {
+			n.SMTP = SMTP{Host: "smtp.example.com", Username: "user", Password: "secret"}
+		}, ""},
+		{"gmail without password", func(n *Notify) { n.Gmail.Email = "me@gmail.com" }, "GMAIL_SMTP_PASS is required"},
+		{"unknown", func(n *Notify) { n.Provider = "pigeon" }, `NOTIFIER "pigeon" is not one of`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Defaults()
+			c.Store.Driver = "memory"
+			tt.setup(&c.Notify)
+
+			err := c.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want a problem containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// envFile holds local overrides of the environment during development.
+const envFile = ".env.local"
+
+// FileFromEnv returns the config file named by CONFIG_FILE, if any.
+func FileFromEnv() string {
+	return os.Getenv("CONFIG_FILE")
+}
+
+// Load builds a Config from, in increasing order of precedence: Defaults,
+// the YAML or TOML file at path (skipped if path is empty), and environment
+// variables, including those set in .env.local. Load does not validate the
+// result; see Validate.
+func Load(path string) (*Config, error) {
+	// godotenv never overrides variables that are already set, so the real
+	// environment wins over .env.local.
+	if err := godotenv.Load(envFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load %s: %w", envFile, err)
+	}
+
+	c := Defaults()
+	if path != "" {
+		if err := loadFile(path, c); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(c).Elem()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadFile decodes a YAML or TOML file, chosen by its extension, over c.
+// Settings the file leaves out keep their current values.
+func loadFile(path string, c *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, c); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".toml":
+		if _, err := toml.Decode(string(data), c); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q (use .yaml, .yml or .toml)", path, ext)
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv overrides each field of the struct v that names an environment
+// variable in its env tag and that variable is set and non-empty. Lists are
+// comma-separated.
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		raw := os.Getenv(name)
+		if name == "" || raw == "" {
+			continue
+		}
+		if err := setField(value, raw); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", name, raw, err)
+		}
+	}
+	return nil
+}
+
+func setField(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("must be a duration such as 30s or 5m")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError lists every problem found in a Config, so that a
+// misconfigured deployment can be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks that c is consistent and that the settings of the selected
+// store and email provider are present. It returns a *ValidationError naming
+// each problem by its environment variable. Optional features that are left
+// unconfigured are reported by Warnings instead.
+func (c *Config) Validate() error {
+	var v validator
+
+	v.require(c.Port, "PORT")
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		v.addf("SERVER_READ_TIMEOUT, SERVER_WRITE_TIMEOUT and SERVER_IDLE_TIMEOUT must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		v.addf("SHUTDOWN_TIMEOUT must be positive")
+	}
+	v.require(c.Term.Code, "TERM_CODE")
+	v.require(c.Term.ShortDescription, "TERM_SHORT_DESCRIPTION")
+	switch c.Store.Driver {
+	case "postgres":
+		v.require(c.Store.PostgresURL, "POSTGRES_URL")
+	case "memory":
+	default:
+		v.addf("STORE %q is not one of postgres, memory", c.Store.Driver)
+	}
+
+	if c.Enroll.RateLimit < 0 {
+		v.addf("ENROLL_RATE_LIMIT must not be negative (0 disables the limit)")
+	}
+	if c.Enroll.RateBurst < 1 {
+		v.addf("ENROLL_RATE_BURST must be at least 1")
+	}
+
+	if c.Check.Interval < 0 || c.Check.Jitter < 0 {
+		v.addf("CHECK_INTERVAL and CHECK_JITTER must not be negative")
+	}
+	if c.Check.Concurrency < 1 {
+		v.addf("CHECK_CONCURRENCY must be at least 1")
+	}
+	switch c.Check.Mode {
+	case "", "course", "batch":
+	default:
+		v.addf("CHECK_MODE %q is not one of course, batch", c.Check.Mode)
+	}
+
+	switch n := c.Notify; n.Selected() {
+	case "smtp":
+		if n.SMTP.Host == "" && n.Gmail.Email != "" {
+			v.require(n.Gmail.Password, "GMAIL_SMTP_PASS")
+		} else if n.SMTP.Host == "" {
+			v.addf("SMTP_HOST (or GMAIL_SMTP_EMAIL and GMAIL_SMTP_PASS) is required when NOTIFIER=smtp")
+		} else {
+			v.require(n.SMTP.Username, "SMTP_USERNAME")
+			v.require(n.SMTP.Password, "SMTP_PASSWORD")
+		}
+	case "mailgun":
+		v.require(n.Mailgun.Domain, "MAILGUN_DOMAIN")
+		v.require(n.Mailgun.APIKey, "MAILGUN_API_KEY")
+		v.require(n.Mailgun.From, "MAILGUN_FROM")
+	case "mailersend":
+		v.require(n.MailerSend.APIKey, "MAILERSEND_API_KEY")
+		v.require(n.MailerSend.From, "MAILERSEND_FROM")
+	case "log":
+	case "":
+		v.addf("NOTIFIER is required: set smtp, mailgun or mailersend with their settings, or log to only log notifications")
+	default:
+		v.addf("NOTIFIER %q is not one of smtp, mailgun, mailersend, log", n.Provider)
+	}
+
+	switch c.Cron.Auth {
+	case "", "none":
+	default:
+		v.addf("CRON_AUTH %q must be none or unset", c.Cron.Auth)
+	}
+	if c.Cron.MaxSkew <= 0 {
+		v.addf("CRON_MAX_SKEW must be positive")
+	}
+
+	if c.Health.Timeout <= 0 {
+		v.addf("READY_TIMEOUT must be positive")
+	}
+
+	return v.err()
+}
+
+// Warnings describes features that c leaves unconfigured. They do not stop
+// the server from starting, but the affected requests will be refused.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if len(c.Google.ClientIDs) == 0 {
+		warnings = append(warnings, "GOOGLE_CLIENT_ID is not set: requests signed in with Google will be refused")
+	}
+	if c.Cron.Auth != "none" && c.Cron.Secret == "" && c.Cron.SigningKey == "" {
+		warnings = append(warnings, "CRON_SECRET and CRON_SIGNING_KEY are not set: /api/cron/check-availability will refuse every request")
+	}
+	if len(c.CORS.AllowedOrigins) == 0 && c.CORS.FrontendURL == "" {
+		warnings = append(warnings, "FRONTEND_URL is not set: only "+localFrontend+" may call the API from a browser")
+	}
+	return warnings
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) require(value, name string) {
+	if value == "" {
+		v.addf("%s is required", name)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
@@ -0,0 +1,137 @@
+package enroll
+
+import (
+	"backend/metrics"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/corpix/uarand"
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/time/rate"
+)
+
+// ErrNotFound is returned when the API has no such course in the requested term.
+var ErrNotFound = errors.New("enroll: not found")
+
+// DefaultBaseURL is the public UW–Madison course search API.
+const DefaultBaseURL = "https://public.enroll.wisc.edu/api/search/v1"
+
+const siteOrigin = "https://public.enroll.wisc.edu"
+
+// Client talks to the enroll search API. It is safe for concurrent use.
+type Client struct {
+	http    *resty.Client
+	baseURL string
+	limiter *rate.Limiter // nil means unlimited
+}
+
+// NewClient returns a Client for the enroll API at baseURL, or at
+// DefaultBaseURL if baseURL is empty.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		http:    resty.New(),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// SetRateLimit caps the client at perSecond requests per second across all
+// goroutines, allowing bursts of up to burst requests. A perSecond of zero
+// or less removes the limit. It must be called before the client is shared.
+func (c *Client) SetRateLimit(perSecond float64, burst int) {
+	if perSecond <= 0 {
+		c.limiter = nil
+		return
+	}
+	c.limiter = rate.NewLimiter(rate.Limit(perSecond), max(burst, 1))
+}
+
+// BaseURL returns the API root the client sends requests to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Search runs a course search.
+func (c *Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	referer := fmt.Sprintf("%s/search?term=%s&keywords=%s", siteOrigin, req.SelectedTerm, url.QueryEscape(req.QueryString))
+
+	var result SearchResponse
+	if err := c.do(ctx, "search", http.MethodPost, c.baseURL, referer, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Ping checks that the API answers a search in the term, asking for a single
+// hit to keep the request cheap.
+func (c *Client) Ping(ctx context.Context, termCode string) error {
+	_, err := c.Search(ctx, SearchRequest{
+		SelectedTerm: termCode,
+		QueryString:  "*",
+		Page:         1,
+		PageSize:     1,
+		SortOrder:    SortScore,
+	})
+	return err
+}
+
+// EnrollmentPackages lists every enrollment package (section combination) of
+// a course, identified exactly by subject code and course ID. It returns
+// ErrNotFound if the course does not exist in the term.
+func (c *Client) EnrollmentPackages(ctx context.Context, termCode, subjectCode, courseID string) ([]EnrollmentPackage, error) {
+	endpoint := fmt.Sprintf("%s/enrollmentPackages/%s/%s/%s", c.baseURL,
+		url.PathEscape(termCode), url.PathEscape(subjectCode), url.PathEscape(courseID))
+	referer := fmt.Sprintf("%s/search?term=%s", siteOrigin, termCode)
+
+	var packages []EnrollmentPackage
+	if err := c.do(ctx, "enrollment_packages", http.MethodGet, endpoint, referer, nil, &packages); err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// do sends one request, recording it in the enroll API metrics under name.
+func (c *Client) do(ctx context.Context, name, method, endpoint, referer string, body, out any) error {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	req := c.http.R().
+		SetContext(ctx).
+		SetHeaders(map[string]string{
+			"Accept":     "application/json, text/plain, */*",
+			"User-Agent": uarand.GetRandom(),
+			"Origin":     siteOrigin,
+			"Referer":    referer,
+		})
+	if body != nil {
+		req.SetHeader("Content-Type", "application/json").SetBody(body)
+	}
+
+	start := time.Now()
+	resp, err := req.Execute(method, endpoint)
+	if err != nil {
+		metrics.ObserveEnrollCall(name, 0, time.Since(start))
+		return err
+	}
+	metrics.ObserveEnrollCall(name, resp.StatusCode(), time.Since(start))
+
+	if resp.StatusCode() == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("API request failed with status: %d", resp.StatusCode())
+	}
+
+	return json.Unmarshal(resp.Body(), out)
+}
@@ -0,0 +1,208 @@
+package enroll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captured is what the test server saw of a request.
+type captured struct {
+	method string
+	path   string
+	header http.Header
+	body   []byte
+}
+
+// newTestServer answers every request with status and the contents of the
+// file at payload, if any, and sends what it saw on the returned channel.
+func newTestServer(t *testing.T, status int, payload string) (*Client, <-chan captured) {
+	t.Helper()
+
+	var data []byte
+	if payload != "" {
+		var err error
+		if data, err = os.ReadFile(payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := make(chan captured, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen <- captured{r.Method, r.URL.Path, r.Header.Clone(), body}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL + "/"), seen
+}
+
+func TestSearchSendsRequest(t *testing.T) {
+	c, seen := newTestServer(t, http.StatusOK, "testdata/search.json")
+
+	req := SearchRequest{
+		SelectedTerm: "1262",
+		QueryString:  "comp sci",
+		Filters:      []Filter{SubjectFilter("266"), CourseIDsFilter("024798", "024799")},
+		Page:         1,
+		PageSize:     50,
+		SortOrder:    SortScore,
+	}
+	if _, err := c.Search(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-seen
+	if got.method != http.MethodPost || got.path != "/" {
+		t.Errorf("request %s %s, want POST to the base URL", got.method, got.path)
+	}
+	var sent SearchRequest
+	if err := json.Unmarshal(got.body, &sent); err != nil {
+		t.Fatalf("body %s: %v", got.body, err)
+	}
+	// Filters round-trip through JSON as generic values.
+	want, _ := json.Marshal(req)
+	if resent, _ := json.Marshal(sent); string(resent) != string(want) {
+		t.Errorf("body = %s, want %s", got.body, want)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json, text/plain, */*",
+		"Origin":       siteOrigin,
+		"Referer":      siteOrigin + "/search?term=1262&keywords=comp+sci",
+	}
+	for name, want := range headers {
+		if v := got.header.Get(name); v != want {
+			t.Errorf("%s = %q, want %q", name, v, want)
+		}
+	}
+	if got.header.Get("User-Agent") == "" {
+		t.Error("no User-Agent sent")
+	}
+}
+
+func TestSearchDecodesResponse(t *testing.T) {
+	c, _ := newTestServer(t, http.StatusOK, "testdata/search.json")
+
+	resp, err := c.Search(context.Background(), SearchRequest{SelectedTerm: "1262", QueryString: "*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Found != 2 || len(resp.Hits) != 2 {
+		t.Fatalf("found %d with %d hits, want 2 and 2", resp.Found, len(resp.Hits))
+	}
+	want := Course{
+		TermCode:                "1262",
+		CourseID:                "024798",
+		Subject:                 Subject{TermCode: "1262", SubjectCode: "266", ShortDescription: "COMP SCI"},
+		CatalogNumber:           "300",
+		CourseDesignation:       "COMP SCI 300",
+		FullCourseDesignation:   "COMPUTER SCIENCES 300",
+		Title:                   "Programming II",
+		Description:             "Introduction to Object-Oriented Programming using classes and objects.",
+		CreditRange:             "3",
+		MinimumCredits:          3,
+		MaximumCredits:          3,
+		EnrollmentPrerequisites: "COMP SCI 200, 220, 302, 310, or 301",
+		TypicallyOffered:        "Fall, Spring, Summer",
+		Repeatable:              "N",
+	}
+	if !reflect.DeepEqual(resp.Hits[0], want) {
+		t.Errorf("first hit = %+v, want %+v", resp.Hits[0], want)
+	}
+}
+
+func TestEnrollmentPackagesSendsRequestAndDecodesResponse(t *testing.T) {
+	c, seen := newTestServer(t, http.StatusOK, "testdata/packages.json")
+
+	packages, err := c.EnrollmentPackages(context.Background(), "1262", "266", "024798")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-seen
+	if got.method != http.MethodGet || got.path != "/enrollmentPackages/1262/266/024798" {
+		t.Errorf("request %s %s, want GET /enrollmentPackages/1262/266/024798", got.method, got.path)
+	}
+	if len(got.body) != 0 || got.header.Get("Content-Type") != "" {
+		t.Errorf("GET sent a body %q of type %q", got.body, got.header.Get("Content-Type"))
+	}
+	if v := got.header.Get("Referer"); v != siteOrigin+"/search?term=1262" {
+		t.Errorf("Referer = %q", v)
+	}
+
+	if len(packages) != 2 {
+		t.Fatalf("decoded %d packages, want 2", len(packages))
+	}
+	p := packages[0]
+	if p.EnrollmentClassNumber != 12001 || p.PackageEnrollmentStatus != (PackageEnrollmentStatus{Status: "OPEN", AvailableSeats: 4}) {
+		t.Errorf("first package = %+v", p)
+	}
+	if p.EnrollmentStatus.Capacity != 200 || p.EnrollmentStatus.OpenSeats != 4 || p.EnrollmentStatus.OpenWaitlistSpots != 40 {
+		t.Errorf("first package enrollment status = %+v", p.EnrollmentStatus)
+	}
+	if label := packages[1].Label(); label != "LEC 002 / DIS 310" {
+		t.Errorf("second package label = %q", label)
+	}
+	if cls := packages[1].Sections[1].ClassUniqueID; cls != (ClassUniqueID{TermCode: "1262", ClassNumber: 12010}) {
+		t.Errorf("discussion section = %+v", cls)
+	}
+}
+
+func TestEnrollmentPackagesNotFound(t *testing.T) {
+	c, _ := newTestServer(t, http.StatusNotFound, "")
+
+	if _, err := c.EnrollmentPackages(context.Background(), "1262", "266", "999999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("EnrollmentPackages = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRequestFailsOnOtherStatus(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusTooManyRequests, http.StatusBadGateway} {
+		c, _ := newTestServer(t, status, "")
+
+		_, err := c.Search(context.Background(), SearchRequest{SelectedTerm: "1262"})
+		if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), strconv.Itoa(status)) {
+			t.Errorf("status %d: Search = %v, want an error with the status", status, err)
+		}
+	}
+}
+
+func TestRateLimitHonoursContext(t *testing.T) {
+	c, seen := newTestServer(t, http.StatusOK, "testdata/packages.json")
+	c.SetRateLimit(0.01, 1) // one request, then one per 100s
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := c.EnrollmentPackages(ctx, "1262", "266", "024798"); err != nil {
+		t.Fatal(err)
+	}
+	<-seen
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.EnrollmentPackages(ctx, "1262", "266", "024798")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("rate-limited request = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("rate-limited request took %s to give up", elapsed)
+	}
+	select {
+	case got := <-seen:
+		t.Errorf("cancelled request was sent: %s %s", got.method, got.path)
+	default:
+	}
+}
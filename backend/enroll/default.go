@@ -0,0 +1,45 @@
+package enroll
+
+import (
+	"backend/config"
+	"sync"
+)
+
+var (
+	defaultMu     sync.Mutex
+	defaultClient *Client
+)
+
+// SetDefault installs c as the Client returned by Default, for example one
+// pointed at a fake enroll server.
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultClient = c
+}
+
+// NewFromConfig returns a Client for cfg.APIURL, or DefaultBaseURL when that
+// is empty, rate limited as cfg says.
+func NewFromConfig(cfg config.Enroll) *Client {
+	c := NewClient(cfg.APIURL)
+	c.SetRateLimit(cfg.RateLimit, cfg.RateBurst)
+	return c
+}
+
+// Default returns the process-wide Client. Unless one has been installed, it
+// is created on first use with NewFromConfig. Should the configuration fail
+// to load, the client falls back to the default settings; the error surfaces
+// where the rest of the configuration is used.
+func Default() *Client {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultClient == nil {
+		cfg := config.Defaults().Enroll
+		if c, err := config.Default(); err == nil {
+			cfg = c.Enroll
+		}
+		defaultClient = NewFromConfig(cfg)
+	}
+	return defaultClient
+}
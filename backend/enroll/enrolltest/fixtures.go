@@ -0,0 +1,67 @@
+package enrolltest
+
+import (
+	"backend/enroll"
+	_ "embed"
+	"encoding/json"
+	"os"
+	"strconv"
+)
+
+// Fixtures is a set of canned courses, as read from a JSON file.
+type Fixtures struct {
+	Courses []CourseFixture `json:"courses"`
+}
+
+// CourseFixture is a course and the scripted states of its enrollment packages.
+type CourseFixture struct {
+	Course enroll.Course                `json:"course"`
+	Steps  [][]enroll.EnrollmentPackage `json:"steps"`
+}
+
+//go:embed testdata/courses.json
+var defaultFixtures []byte
+
+// DefaultFixtures returns a small built-in catalogue of courses whose
+// sections open, waitlist and close over a few steps.
+func DefaultFixtures() (Fixtures, error) {
+	var f Fixtures
+	err := json.Unmarshal(defaultFixtures, &f)
+	return f, err
+}
+
+// LoadFixturesFile reads fixtures from a JSON file.
+func LoadFixturesFile(path string) (Fixtures, error) {
+	var f Fixtures
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return f, err
+	}
+	err = json.Unmarshal(data, &f)
+	return f, err
+}
+
+// Package builds an enrollment package with a single section, which is
+// enough for most scripted scenarios.
+func Package(classNumber int, sectionType, sectionNumber, status string, openSeats, waitlistSize int) enroll.EnrollmentPackage {
+	return enroll.EnrollmentPackage{
+		ID:                    strconv.Itoa(classNumber),
+		EnrollmentClassNumber: classNumber,
+		PackageEnrollmentStatus: enroll.PackageEnrollmentStatus{
+			Status:         status,
+			AvailableSeats: openSeats,
+			WaitlistTotal:  waitlistSize,
+		},
+		EnrollmentStatus: enroll.EnrollmentStatus{
+			OpenSeats:           openSeats,
+			WaitlistCurrentSize: waitlistSize,
+		},
+		Sections: []enroll.Section{
+			{
+				Type:          sectionType,
+				SectionNumber: sectionNumber,
+				ClassUniqueID: enroll.ClassUniqueID{ClassNumber: classNumber},
+			},
+		},
+	}
+}
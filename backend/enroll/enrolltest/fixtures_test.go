@@ -0,0 +1,56 @@
+package enrolltest
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDefaultFixtures(t *testing.T) {
+	f, err := DefaultFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Courses) == 0 {
+		t.Fatal("the built-in catalogue is empty")
+	}
+
+	statuses := []string{"OPEN", "WAITLISTED", "CLOSED"}
+	for _, c := range f.Courses {
+		name := c.Course.CourseDesignation
+		if c.Course.CourseID == "" || c.Course.Subject.SubjectCode == "" || c.Course.TermCode == "" || c.Course.Title == "" {
+			t.Errorf("%s: incomplete course %+v", name, c.Course)
+		}
+		if len(c.Steps) == 0 {
+			t.Errorf("%s: no scripted steps", name)
+		}
+		for i, step := range c.Steps {
+			if len(step) == 0 {
+				t.Errorf("%s: step %d has no packages", name, i)
+			}
+			for _, p := range step {
+				if p.EnrollmentClassNumber == 0 || len(p.Sections) == 0 || !slices.Contains(statuses, p.PackageEnrollmentStatus.Status) {
+					t.Errorf("%s: step %d has an incomplete package %+v", name, i, p)
+				}
+			}
+		}
+	}
+}
+
+func TestLoadFixturesFile(t *testing.T) {
+	f, err := LoadFixturesFile("testdata/courses.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtIn, err := DefaultFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, builtIn) {
+		t.Error("the file decodes differently from the built-in catalogue")
+	}
+
+	if _, err := LoadFixturesFile("testdata/missing.json"); err == nil {
+		t.Error("loading a missing file succeeded")
+	}
+}
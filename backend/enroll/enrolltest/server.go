@@ -0,0 +1,288 @@
+// Package enrolltest provides a fake enroll search API for tests and local
+// development, in the spirit of net/http/httptest.
+package enrolltest
+
+import (
+	"backend/enroll"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Handler is a fake enroll API serving canned courses. Each course plays
+// back a script of enrollment package states; Advance moves every course to
+// its next step, so tests can drive status transitions between cron runs.
+type Handler struct {
+	mu       sync.Mutex
+	courses  []*course
+	requests int
+}
+
+// NewHandler returns a fake enroll API with no courses, for serving on a
+// listener of the caller's choosing.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// Server is a Handler served by an httptest.Server on a local port.
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+type course struct {
+	enroll.Course
+	steps [][]enroll.EnrollmentPackage
+	step  int
+}
+
+func (c *course) packages() []enroll.EnrollmentPackage {
+	if len(c.steps) == 0 {
+		return nil
+	}
+	return c.steps[c.step]
+}
+
+// NewServer starts a fake enroll server with no courses. Callers should
+// Close it when finished.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a fake enroll server that has not been started,
+// so the caller can change its configuration before calling Start.
+func NewUnstartedServer() *Server {
+	h := NewHandler()
+	return &Server{Server: httptest.NewUnstartedServer(h), Handler: h}
+}
+
+// Client returns an enroll.Client pointed at the fake server.
+func (s *Server) Client() *enroll.Client {
+	return enroll.NewClient(s.URL)
+}
+
+// AddCourse registers a course whose enrollment packages follow steps. The
+// first step is served until Advance is called; the last step is served
+// indefinitely once reached. Adding a course that already exists replaces it.
+// Packages missing their course keys inherit them from c.
+func (h *Handler) AddCourse(c enroll.Course, steps ...[]enroll.EnrollmentPackage) {
+	for _, step := range steps {
+		for i := range step {
+			if step[i].CourseID == "" {
+				step[i].CourseID = c.CourseID
+			}
+			if step[i].SubjectCode == "" {
+				step[i].SubjectCode = c.Subject.SubjectCode
+			}
+			if step[i].TermCode == "" {
+				step[i].TermCode = c.TermCode
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, existing := range h.courses {
+		if existing.CourseID == c.CourseID && existing.Subject.SubjectCode == c.Subject.SubjectCode {
+			h.courses[i] = &course{Course: c, steps: steps}
+			return
+		}
+	}
+	h.courses = append(h.courses, &course{Course: c, steps: steps})
+}
+
+// RemoveCourse drops a course, as if it had been withdrawn from the term.
+func (h *Handler) RemoveCourse(subjectCode, courseID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.courses = slices.DeleteFunc(h.courses, func(c *course) bool {
+		return c.CourseID == courseID && c.Subject.SubjectCode == subjectCode
+	})
+}
+
+// LoadFixtures registers every course in f.
+func (h *Handler) LoadFixtures(f Fixtures) {
+	for _, c := range f.Courses {
+		h.AddCourse(c.Course, c.Steps...)
+	}
+}
+
+// Advance moves every scripted course to its next step.
+func (h *Handler) Advance() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, c := range h.courses {
+		if c.step < len(c.steps)-1 {
+			c.step++
+		}
+	}
+}
+
+// Requests returns the number of API requests served so far.
+func (h *Handler) Requests() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+// ServeHTTP implements the search endpoint at "/" and the enrollment
+// packages endpoint at "/enrollmentPackages/{term}/{subject}/{courseId}".
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests++
+	h.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/" || r.URL.Path == "":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.search(w, r)
+	case strings.HasPrefix(r.URL.Path, "/enrollmentPackages/"):
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.enrollmentPackages(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) search(w http.ResponseWriter, r *http.Request) {
+	var req enroll.SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	var matches []enroll.Course
+	for _, c := range h.courses {
+		if matchesSearch(c, req) {
+			matches = append(matches, c.Course)
+		}
+	}
+	h.mu.Unlock()
+
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	start := min((page-1)*pageSize, len(matches))
+	end := min(start+pageSize, len(matches))
+
+	writeJSON(w, enroll.SearchResponse{
+		Found: len(matches),
+		Hits:  append([]enroll.Course{}, matches[start:end]...),
+	})
+}
+
+func (h *Handler) enrollmentPackages(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/enrollmentPackages/"), "/")
+	if len(parts) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+	termCode, subjectCode, courseID := parts[0], parts[1], parts[2]
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, c := range h.courses {
+		if c.CourseID == courseID && c.Subject.SubjectCode == subjectCode && matchesTerm(c, termCode) {
+			writeJSON(w, append([]enroll.EnrollmentPackage{}, c.packages()...))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func matchesTerm(c *course, termCode string) bool {
+	return c.TermCode == "" || termCode == "" || c.TermCode == termCode
+}
+
+// matchesSearch approximates the real search: every query word must appear
+// in the course designation or title, subject and course ID filters must
+// match, and every enrollmentPackage filter must be satisfied by at least one
+// of the course's current packages.
+func matchesSearch(c *course, req enroll.SearchRequest) bool {
+	if !matchesTerm(c, req.SelectedTerm) {
+		return false
+	}
+
+	if q := strings.TrimSpace(req.QueryString); q != "" && q != "*" {
+		text := strings.ToLower(c.FullCourseDesignation + " " + c.CourseDesignation + " " + c.Title)
+		for _, word := range strings.Fields(strings.ToLower(q)) {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+	}
+
+	for _, f := range req.Filters {
+		if code, ok := f.Term["subject.subjectCode"]; ok && code != c.Subject.SubjectCode {
+			return false
+		}
+		if ids, ok := f.Terms["courseId"]; ok && !slices.Contains(ids, c.CourseID) {
+			return false
+		}
+		if f.HasChild == nil || f.HasChild.Type != "enrollmentPackage" {
+			continue
+		}
+		found := false
+		for _, p := range c.packages() {
+			if matchesPackage(p, f.HasChild.Query.Bool.Must) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPackage evaluates match clauses against a package. Like an
+// Elasticsearch match query, a space-separated string matches any of its words.
+func matchesPackage(p enroll.EnrollmentPackage, must []enroll.Match) bool {
+	for _, m := range must {
+		for field, value := range m.Match {
+			switch field {
+			case "packageEnrollmentStatus.status":
+				want, _ := value.(string)
+				if !containsWord(want, p.PackageEnrollmentStatus.Status) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func containsWord(words, word string) bool {
+	for _, w := range strings.Fields(words) {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
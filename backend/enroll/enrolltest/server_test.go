@@ -0,0 +1,112 @@
+package enrolltest
+
+import (
+	"backend/enroll"
+	"context"
+	"errors"
+	"testing"
+)
+
+// newFixtureServer serves the built-in catalogue.
+func newFixtureServer(t *testing.T) *Server {
+	t.Helper()
+	f, err := DefaultFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer()
+	t.Cleanup(s.Close)
+	s.LoadFixtures(f)
+	return s
+}
+
+func packageStatus(t *testing.T, c *enroll.Client, subjectCode, courseID string) string {
+	t.Helper()
+	packages, err := c.EnrollmentPackages(context.Background(), "1262", subjectCode, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 1 {
+		t.Fatalf("%s/%s has %d packages, want 1", subjectCode, courseID, len(packages))
+	}
+	return packages[0].PackageEnrollmentStatus.Status
+}
+
+func TestAdvanceStepsScriptedCourses(t *testing.T) {
+	s := newFixtureServer(t)
+	c := s.Client()
+
+	// COMP SCI 300 is scripted to go from closed to waitlisted to open, and
+	// 010421 to stay waitlisted.
+	for _, want := range []string{"CLOSED", "WAITLISTED", "OPEN", "OPEN"} {
+		if got := packageStatus(t, c, "266", "024798"); got != want {
+			t.Errorf("COMP SCI 300 is %s, want %s", got, want)
+		}
+		if got := packageStatus(t, c, "220", "010421"); got != "WAITLISTED" {
+			t.Errorf("single-step course is %s, want WAITLISTED", got)
+		}
+		s.Advance()
+	}
+}
+
+func TestSearchFiltersByCurrentStep(t *testing.T) {
+	s := newFixtureServer(t)
+	c := s.Client()
+
+	search := func() []string {
+		t.Helper()
+		resp, err := c.Search(context.Background(), enroll.SearchRequest{
+			SelectedTerm: "1262",
+			QueryString:  "*",
+			Filters: []enroll.Filter{
+				enroll.SubjectFilter("266"),
+				enroll.PackageFilter(enroll.MatchField("packageEnrollmentStatus.status", "OPEN WAITLISTED")),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, hit := range resp.Hits {
+			ids = append(ids, hit.CourseID)
+		}
+		return ids
+	}
+
+	if ids := search(); len(ids) != 0 {
+		t.Errorf("closed course matched an enrollable search: %v", ids)
+	}
+	s.Advance()
+	if ids := search(); len(ids) != 1 || ids[0] != "024798" {
+		t.Errorf("search after Advance = %v, want the now waitlisted 024798", ids)
+	}
+}
+
+func TestRemoveCourse(t *testing.T) {
+	s := newFixtureServer(t)
+	c := s.Client()
+
+	s.RemoveCourse("266", "024798")
+	if _, err := c.EnrollmentPackages(context.Background(), "1262", "266", "024798"); !errors.Is(err, enroll.ErrNotFound) {
+		t.Errorf("EnrollmentPackages of a removed course = %v, want ErrNotFound", err)
+	}
+	resp, err := c.Search(context.Background(), enroll.SearchRequest{
+		SelectedTerm: "1262",
+		QueryString:  "*",
+		Filters:      []enroll.Filter{enroll.SubjectFilter("266")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Found != 0 {
+		t.Errorf("search found %d courses after removal: %+v", resp.Found, resp.Hits)
+	}
+
+	// Other courses are unaffected.
+	if got := packageStatus(t, c, "600", "015386"); got != "OPEN" {
+		t.Errorf("remaining course is %s, want OPEN", got)
+	}
+	if n := s.Requests(); n != 3 {
+		t.Errorf("Requests = %d, want 3", n)
+	}
+}
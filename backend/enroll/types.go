@@ -0,0 +1,156 @@
+package enroll
+
+import "strings"
+
+// SearchRequest is the body POSTed to the enroll search endpoint.
+type SearchRequest struct {
+	SelectedTerm string   `json:"selectedTerm"`
+	QueryString  string   `json:"queryString"`
+	Filters      []Filter `json:"filters"`
+	Page         int      `json:"page"`
+	PageSize     int      `json:"pageSize"`
+	SortOrder    string   `json:"sortOrder"`
+}
+
+// SortScore orders search hits by relevance to the query string.
+const SortScore = "SCORE"
+
+// Filter restricts search hits. The enroll API accepts a subset of the
+// Elasticsearch query DSL; only the clauses we use are modelled here.
+type Filter struct {
+	HasChild *HasChild           `json:"has_child,omitempty"`
+	Term     map[string]any      `json:"term,omitempty"`
+	Terms    map[string][]string `json:"terms,omitempty"`
+}
+
+// HasChild matches courses with at least one child document of Type
+// (for example "enrollmentPackage") satisfying Query.
+type HasChild struct {
+	Type  string `json:"type"`
+	Query Query  `json:"query"`
+}
+
+// Query is a boolean query over child document fields.
+type Query struct {
+	Bool BoolQuery `json:"bool"`
+}
+
+// BoolQuery requires every clause in Must to match.
+type BoolQuery struct {
+	Must []Match `json:"must"`
+}
+
+// Match is a single field match clause.
+type Match struct {
+	Match map[string]any `json:"match"`
+}
+
+// MatchField returns a clause matching field against value.
+func MatchField(field string, value any) Match {
+	return Match{Match: map[string]any{field: value}}
+}
+
+// PackageFilter matches courses with an enrollment package satisfying all of must.
+func PackageFilter(must ...Match) Filter {
+	return Filter{
+		HasChild: &HasChild{
+			Type:  "enrollmentPackage",
+			Query: Query{Bool: BoolQuery{Must: must}},
+		},
+	}
+}
+
+// SubjectFilter matches courses offered by the subject with the given code.
+func SubjectFilter(subjectCode string) Filter {
+	return Filter{Term: map[string]any{"subject.subjectCode": subjectCode}}
+}
+
+// CourseIDsFilter matches courses whose courseId is any of ids.
+func CourseIDsFilter(ids ...string) Filter {
+	return Filter{Terms: map[string][]string{"courseId": ids}}
+}
+
+// SearchResponse is the result of a course search.
+type SearchResponse struct {
+	Found int      `json:"found"`
+	Hits  []Course `json:"hits"`
+}
+
+// Course is a single search hit.
+type Course struct {
+	TermCode                string  `json:"termCode"`
+	CourseID                string  `json:"courseId"`
+	Subject                 Subject `json:"subject"`
+	CatalogNumber           string  `json:"catalogNumber"`
+	CourseDesignation       string  `json:"courseDesignation"`
+	FullCourseDesignation   string  `json:"fullCourseDesignation"`
+	Title                   string  `json:"title"`
+	Description             string  `json:"description"`
+	CreditRange             string  `json:"creditRange"`
+	MinimumCredits          int     `json:"minimumCredits"`
+	MaximumCredits          int     `json:"maximumCredits"`
+	EnrollmentPrerequisites string  `json:"enrollmentPrerequisites"`
+	TypicallyOffered        string  `json:"typicallyOffered"`
+	Repeatable              string  `json:"repeatable"`
+}
+
+// Subject is the department offering a course.
+type Subject struct {
+	TermCode         string `json:"termCode"`
+	SubjectCode      string `json:"subjectCode"`
+	ShortDescription string `json:"shortDescription"`
+	LongDescription  string `json:"longDescription"`
+}
+
+// EnrollmentPackage is one enrollable combination of sections of a course,
+// such as a lecture paired with a discussion.
+type EnrollmentPackage struct {
+	ID                      string                  `json:"id"`
+	TermCode                string                  `json:"termCode"`
+	SubjectCode             string                  `json:"subjectCode"`
+	CourseID                string                  `json:"courseId"`
+	EnrollmentClassNumber   int                     `json:"enrollmentClassNumber"`
+	PackageEnrollmentStatus PackageEnrollmentStatus `json:"packageEnrollmentStatus"`
+	EnrollmentStatus        EnrollmentStatus        `json:"enrollmentStatus"`
+	Sections                []Section               `json:"sections"`
+}
+
+// Label describes the sections in a package, e.g. "LEC 001 / DIS 301".
+func (p EnrollmentPackage) Label() string {
+	parts := make([]string, 0, len(p.Sections))
+	for _, s := range p.Sections {
+		parts = append(parts, s.Type+" "+s.SectionNumber)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// PackageEnrollmentStatus summarises whether a package can be enrolled in.
+// Status is one of "OPEN", "WAITLISTED" or "CLOSED".
+type PackageEnrollmentStatus struct {
+	Status         string `json:"status"`
+	AvailableSeats int    `json:"availableSeats"`
+	WaitlistTotal  int    `json:"waitlistTotal"`
+}
+
+// EnrollmentStatus holds the seat and waitlist counts of a package.
+type EnrollmentStatus struct {
+	Capacity            int `json:"capacity"`
+	CurrentlyEnrolled   int `json:"currentlyEnrolled"`
+	OpenSeats           int `json:"openSeats"`
+	WaitlistCapacity    int `json:"waitlistCapacity"`
+	WaitlistCurrentSize int `json:"waitlistCurrentSize"`
+	OpenWaitlistSpots   int `json:"openWaitlistSpots"`
+}
+
+// Section is a single lecture, discussion or lab within a package.
+type Section struct {
+	Type          string        `json:"type"`
+	SectionNumber string        `json:"sectionNumber"`
+	ClassUniqueID ClassUniqueID `json:"classUniqueId"`
+}
+
+// ClassUniqueID identifies a section within a term.
+type ClassUniqueID struct {
+	TermCode    string `json:"termCode"`
+	ClassNumber int    `json:"classNumber"`
+}
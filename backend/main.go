@@ -1,37 +1,56 @@
 package main
 
 import (
-	"backend/api/courses"
-	checkAvailability "backend/api/cron/check-availability"
-	"backend/api/register"
-	"backend/api/subscribe"
-	"backend/api/subscriptions"
-	"backend/api/unsubscribe"
+	"backend/config"
+	"backend/server"
+	"backend/server/cors"
+	"backend/store"
+	"context"
+	"errors"
 	"log"
-	"net/http"
-
-	"github.com/joho/godotenv"
+	"os"
 )
 
-func init() {
-	// Loads variables from a .env file into Go's environment.
-	err := godotenv.Load(".env.local")
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	// Load every setting up front and refuse to start with an incomplete
+	// configuration, rather than failing on the first request that needs it.
+	cfg, err := config.Load(config.FileFromEnv())
 	if err != nil {
-		log.Println("No .env.local file found or error loading it.")
+		log.Fatal("Config error: ", err)
 	}
-  }
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	for _, warning := range cfg.Warnings() {
+		log.Println("Warning:", warning)
+	}
+	config.SetDefault(cfg)
 
-func main() {
-	// API routes
-	http.HandleFunc("/api/courses", courses.Handler)
-	http.HandleFunc("/api/register", register.Handler)
-	http.HandleFunc("/api/subscribe", subscribe.Handler)
-	http.HandleFunc("/api/unsubscribe", unsubscribe.Handler)
-	http.HandleFunc("/api/subscriptions", subscriptions.Handler)
-	http.HandleFunc("/api/cron/check-availability", checkAvailability.Handler)
+	policy, err := cors.FromConfig(cfg.CORS)
+	if err != nil {
+		log.Fatalf("Invalid CORS configuration: %v", err)
+	}
+	cors.SetDefault(policy)
 
+	// Share one connection pool across every handler for the life of the server.
+	st, err := store.Open(context.Background(), cfg.Store)
+	if err != nil {
+		log.Fatal("DB connection error:", err)
+	}
+	store.SetDefault(st)
 
-	port := ":8000"
-	log.Printf("🚀 Local server running on http://localhost%s", port)
-	log.Fatal(http.ListenAndServe(port, nil))
+	err = serve(cfg, server.NewRouter(policy))
+	// Close the pool only once every request and check has let go of it.
+	if !errors.Is(err, errShutdownStuck) {
+		st.Close()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Server stopped")
 }
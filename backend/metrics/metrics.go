@@ -0,0 +1,140 @@
+// Package metrics defines the Prometheus metrics the backend exports on
+// /metrics, and the functions the rest of the code records them with.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bct"
+
+// checkBuckets span the seconds an availability check can take, up to the
+// cron endpoint's ten minute limit.
+var checkBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600}
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "API requests handled, by route pattern, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to handle API requests, by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	enrollRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "enroll_requests_total",
+		Help:      "Calls to the enroll API, by endpoint and status code (\"error\" if no response arrived).",
+	}, []string{"endpoint", "code"})
+
+	enrollDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "enroll_request_duration_seconds",
+		Help:      "Latency of calls to the enroll API, excluding time spent waiting on the rate limit.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	checkRuns = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "check_run_duration_seconds",
+		Help:      "Duration of availability check runs, by result.",
+		Buckets:   checkBuckets,
+	}, []string{"result"})
+
+	checkRunCourses = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "check_run_courses_checked",
+		Help:      "Courses checked by the last availability check run that completed without error.",
+	})
+
+	checkLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "check_last_success_timestamp_seconds",
+		Help:      "Unix time at which an availability check run last completed without error.",
+	})
+
+	courseChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "course_checks_total",
+		Help:      "Individual course checks, by result.",
+	}, []string{"result"})
+
+	transitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "status_transitions_total",
+		Help:      "Recorded availability status changes of courses and sections, by scope and statuses.",
+	}, []string{"scope", "from", "to"})
+
+	emails = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_total",
+		Help:      "Notification emails attempted, by provider and result.",
+	}, []string{"provider", "result"})
+)
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest records an API request matched by the route pattern.
+func ObserveRequest(route, method string, code int, elapsed time.Duration) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(route, method).Observe(elapsed.Seconds())
+}
+
+// ObserveEnrollCall records a call to an enroll API endpoint. A code of zero
+// means the call failed without a response.
+func ObserveEnrollCall(endpoint string, code int, elapsed time.Duration) {
+	label := "error"
+	if code != 0 {
+		label = strconv.Itoa(code)
+	}
+	enrollRequests.WithLabelValues(endpoint, label).Inc()
+	enrollDuration.WithLabelValues(endpoint).Observe(elapsed.Seconds())
+}
+
+// ObserveCheckRun records a finished availability check run. The number of
+// courses it checked is only kept from runs that succeeded, since a failed
+// run may have stopped part way.
+func ObserveCheckRun(elapsed time.Duration, courses int, err error) {
+	checkRuns.WithLabelValues(result(err, "ok")).Observe(elapsed.Seconds())
+	if err == nil {
+		checkRunCourses.Set(float64(courses))
+		checkLastSuccess.SetToCurrentTime()
+	}
+}
+
+// ObserveCourseCheck records the outcome of checking one course.
+func ObserveCourseCheck(err error) {
+	courseChecks.WithLabelValues(result(err, "ok")).Inc()
+}
+
+// ObserveTransition records a recorded status change of a course or section;
+// scope is "course" or "section".
+func ObserveTransition(scope, from, to string) {
+	transitions.WithLabelValues(scope, from, to).Inc()
+}
+
+// ObserveEmail records an attempt to send an email through provider.
+func ObserveEmail(provider string, err error) {
+	emails.WithLabelValues(provider, result(err, "sent")).Inc()
+}
+
+func result(err error, success string) string {
+	if err != nil {
+		return "failed"
+	}
+	return success
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sample scrapes Handler for the value of the series named, with its labels,
+// by series, or reports false if it has not been recorded.
+func sample(t *testing.T, series string) (float64, bool) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("%s: %v", series, err)
+			}
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func TestObserveCheckRunKeepsCoursesOfLastSuccess(t *testing.T) {
+	ObserveCheckRun(time.Second, 12, nil)
+	succeeded, _ := sample(t, "bct_check_last_success_timestamp_seconds")
+	if got, _ := sample(t, "bct_check_run_courses_checked"); got != 12 {
+		t.Fatalf("courses checked = %v after a successful run, want 12", got)
+	}
+
+	ObserveCheckRun(time.Second, 3, errors.New("lease lost"))
+	if got, _ := sample(t, "bct_check_run_courses_checked"); got != 12 {
+		t.Errorf("courses checked = %v after a failed run, want the successful run's 12", got)
+	}
+	if got, _ := sample(t, "bct_check_last_success_timestamp_seconds"); got != succeeded {
+		t.Errorf("last success moved from %v to %v on a failed run", succeeded, got)
+	}
+	if n, ok := sample(t, `bct_check_run_duration_seconds_count{result="failed"}`); !ok || n < 1 {
+		t.Errorf("failed run was not recorded (count %v)", n)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"backend/config"
+	"backend/store"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+const migrateUsage = "usage: backend migrate up | down [steps] | status"
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	cfg, err := config.Load(config.FileFromEnv())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Store.PostgresURL == "" {
+		log.Fatal("POSTGRES_URL is required to run migrations")
+	}
+
+	ctx := context.Background()
+	pg, err := store.NewPostgres(ctx, cfg.Store.PostgresURL)
+	if err != nil {
+		log.Fatal("DB connection error:", err)
+	}
+	defer pg.Close()
+
+	switch args[0] {
+	case "up":
+		ran, err := pg.MigrateUp(ctx)
+		for _, m := range ran {
+			log.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(ran) == 0 {
+			log.Println("Schema is up to date.")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatal(migrateUsage)
+			}
+		}
+		ran, err := pg.MigrateDown(ctx, steps)
+		for _, m := range ran {
+			log.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	case "status":
+		migrations, err := pg.MigrationStatus(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, m := range migrations {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = "applied " + m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", m.Version, m.Name, applied)
+		}
+
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
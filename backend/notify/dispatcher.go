@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"backend/metrics"
+	"backend/store"
+	"context"
+	"log"
+	"time"
+)
+
+// Dispatcher delivers notifications queued in the store's outbox, retrying
+// failed deliveries with exponential backoff until MaxAttempts is reached.
+type Dispatcher struct {
+	Store    store.Store
+	Notifier Notifier
+
+	MaxAttempts int           // failed attempts before an entry is marked dead
+	BaseDelay   time.Duration // wait after the first failure; doubles per retry
+	MaxDelay    time.Duration // upper bound on the wait between retries
+	BatchSize   int           // entries claimed per round trip to the store
+	Lease       time.Duration // how long a claimed entry is hidden from other dispatchers
+}
+
+// NewDispatcher returns a Dispatcher with default retry settings: five
+// attempts, starting one minute apart and backing off to at most an hour.
+func NewDispatcher(st store.Store, n Notifier) *Dispatcher {
+	return &Dispatcher{
+		Store:       st,
+		Notifier:    n,
+		MaxAttempts: 5,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		BatchSize:   50,
+		Lease:       5 * time.Minute,
+	}
+}
+
+// DispatchResult counts the outcomes of one Dispatch call.
+type DispatchResult struct {
+	Sent   int
+	Failed int // will be retried
+	Dead   int // gave up
+}
+
+// Dispatch delivers every notification that is currently due.
+func (d *Dispatcher) Dispatch(ctx context.Context) (DispatchResult, error) {
+	var result DispatchResult
+	for {
+		batch, err := d.Store.ClaimNotifications(ctx, d.BatchSize, d.Lease)
+		if err != nil {
+			return result, err
+		}
+		if len(batch) == 0 {
+			return result, nil
+		}
+
+		for _, n := range batch {
+			if err := ctx.Err(); err != nil {
+				// Unsent claimed entries become due again once their lease expires.
+				return result, err
+			}
+			d.deliver(ctx, n, &result)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, n store.Notification, result *DispatchResult) {
+	msg := Message{To: n.Recipient, Subject: n.Subject, HTML: n.HTML}
+	sendErr := d.Notifier.Send(ctx, msg)
+	metrics.ObserveEmail(d.Notifier.Name(), sendErr)
+	if sendErr == nil {
+		if err := d.Store.MarkNotificationSent(ctx, n.ID); err != nil {
+			log.Printf("Error marking notification %d sent: %v\n", n.ID, err)
+		}
+		log.Printf("Notification sent to %s via %s\n", n.Recipient, d.Notifier.Name())
+		result.Sent++
+		return
+	}
+
+	attempt := n.Attempts + 1
+	dead := attempt >= d.MaxAttempts
+	next := time.Now().Add(d.backoff(attempt))
+	if err := d.Store.MarkNotificationFailed(ctx, n.ID, sendErr.Error(), next, dead); err != nil {
+		log.Printf("Error recording failed notification %d: %v\n", n.ID, err)
+	}
+
+	if dead {
+		log.Printf("Giving up on notification %d to %s after %d attempts: %v\n", n.ID, n.Recipient, attempt, sendErr)
+		result.Dead++
+	} else {
+		log.Printf("Error sending email to %s via %s (attempt %d): %v\n", n.Recipient, d.Notifier.Name(), attempt, sendErr)
+		result.Failed++
+	}
+}
+
+// backoff returns the wait before retrying after the given failed attempt.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.BaseDelay
+	for i := 1; i < attempt && delay < d.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.MaxDelay)
+}
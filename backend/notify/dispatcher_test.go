@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"backend/store"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyNotifier fails as many sends as its failures count, then succeeds.
+type flakyNotifier struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+}
+
+func (n *flakyNotifier) Name() string { return "flaky" }
+
+func (n *flakyNotifier) Send(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.attempts++
+	if n.attempts <= n.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// failure is one MarkNotificationFailed call.
+type failure struct {
+	deliveryErr string
+	nextAttempt time.Time
+	dead        bool
+}
+
+// failureStore records how failed deliveries are marked.
+type failureStore struct {
+	*store.Memory
+	failures []failure
+}
+
+func (s *failureStore) MarkNotificationFailed(ctx context.Context, id int64, deliveryErr string, nextAttempt time.Time, dead bool) error {
+	s.failures = append(s.failures, failure{deliveryErr, nextAttempt, dead})
+	return s.Memory.MarkNotificationFailed(ctx, id, deliveryErr, nextAttempt, dead)
+}
+
+// newQueue returns a store with one notification waiting in the outbox.
+func newQueue(t *testing.T) *failureStore {
+	t.Helper()
+	st := &failureStore{Memory: store.NewMemory()}
+	err := st.RecordCheck(context.Background(),
+		store.CourseAvailability{CourseID: "024798", CourseSubjectCode: "266", Status: store.StatusOpen},
+		nil,
+		[]store.Notification{{Recipient: "student@example.com", Subject: "Update", HTML: "<p>Open</p>", CourseID: "024798", CourseSubjectCode: "266"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestDispatcherRetriesUntilSent(t *testing.T) {
+	st := newQueue(t)
+	n := &flakyNotifier{failures: 2}
+	d := NewDispatcher(st, n)
+	d.BaseDelay, d.MaxDelay = 0, 0 // retry within the same Dispatch
+
+	result, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (DispatchResult{Sent: 1, Failed: 2}); result != want {
+		t.Errorf("Dispatch = %+v, want %+v", result, want)
+	}
+	if len(st.failures) != 2 || st.failures[0].deliveryErr != "connection refused" || st.failures[1].dead {
+		t.Errorf("failures marked as %+v", st.failures)
+	}
+
+	// Nothing is left to send.
+	result, err = d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (DispatchResult{}) || n.attempts != 3 {
+		t.Errorf("second Dispatch = %+v after %d attempts, want nothing sent", result, n.attempts)
+	}
+}
+
+func TestDispatcherMarksDeadAfterMaxAttempts(t *testing.T) {
+	st := newQueue(t)
+	n := &flakyNotifier{failures: 10}
+	d := NewDispatcher(st, n)
+	d.MaxAttempts = 3
+	d.BaseDelay, d.MaxDelay = 0, 0
+
+	result, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (DispatchResult{Failed: 2, Dead: 1}); result != want {
+		t.Errorf("Dispatch = %+v, want %+v", result, want)
+	}
+	if len(st.failures) != 3 || !st.failures[2].dead {
+		t.Errorf("failures marked as %+v, want the third dead", st.failures)
+	}
+
+	// A dead notification is never retried.
+	if _, err := d.Dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n.attempts != 3 {
+		t.Errorf("dead notification was attempted %d times, want 3", n.attempts)
+	}
+}
+
+func TestDispatcherWaitsBeforeRetrying(t *testing.T) {
+	st := newQueue(t)
+	n := &flakyNotifier{failures: 1}
+	d := NewDispatcher(st, n)
+
+	start := time.Now()
+	result, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (DispatchResult{Failed: 1}); result != want {
+		t.Errorf("Dispatch = %+v, want %+v", result, want)
+	}
+	if len(st.failures) != 1 || st.failures[0].nextAttempt.Before(start.Add(d.BaseDelay)) {
+		t.Errorf("failure marked as %+v, want a retry after %s", st.failures, d.BaseDelay)
+	}
+
+	// The retry is not due yet.
+	result, err = d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (DispatchResult{}) || n.attempts != 1 {
+		t.Errorf("Dispatch before the retry was due = %+v after %d attempts", result, n.attempts)
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := NewDispatcher(nil, nil)
+	want := []time.Duration{
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		8 * time.Minute,
+		16 * time.Minute,
+		32 * time.Minute,
+		time.Hour,
+		time.Hour,
+	}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
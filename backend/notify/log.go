@@ -0,0 +1,16 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// Log is a sink that only logs messages, for local development and dry runs.
+type Log struct{}
+
+func (Log) Name() string { return "log" }
+
+func (Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("[notify] to=%s subject=%q\n", msg.To, msg.Subject)
+	return nil
+}
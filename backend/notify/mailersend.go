@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/mailersend/mailersend-go"
+)
+
+// MailerSend sends mail through the MailerSend HTTP API.
+type MailerSend struct {
+	client *mailersend.Mailersend
+	from   string
+}
+
+// NewMailerSend returns a MailerSend notifier sending from a verified address.
+func NewMailerSend(apiKey, from string) *MailerSend {
+	return &MailerSend{client: mailersend.NewMailersend(apiKey), from: from}
+}
+
+func (m *MailerSend) Name() string { return "mailersend" }
+
+func (m *MailerSend) Send(ctx context.Context, msg Message) error {
+	message := m.client.Email.NewMessage()
+	message.SetFrom(mailersend.From{Email: m.from})
+	message.SetRecipients([]mailersend.Recipient{{Email: msg.To}})
+	message.SetSubject(msg.Subject)
+	message.SetHTML(msg.HTML)
+	_, err := m.client.Email.Send(ctx, message)
+	return err
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"backend/config"
+	"context"
+
+	"github.com/mailgun/mailgun-go/v4"
+)
+
+// Mailgun sends mail through the Mailgun HTTP API.
+type Mailgun struct {
+	client *mailgun.MailgunImpl
+	from   string
+}
+
+// NewMailgun returns a Mailgun notifier for a verified sending domain.
+func NewMailgun(domain, apiKey, from string) *Mailgun {
+	return &Mailgun{client: mailgun.NewMailgun(domain, apiKey), from: from}
+}
+
+// MailgunFromConfig configures Mailgun from cfg. Set cfg.APIBase to use a
+// non-US region.
+func MailgunFromConfig(cfg config.Mailgun) *Mailgun {
+	m := NewMailgun(cfg.Domain, cfg.APIKey, cfg.From)
+	if cfg.APIBase != "" {
+		m.client.SetAPIBase(cfg.APIBase)
+	}
+	return m
+}
+
+func (m *Mailgun) Name() string { return "mailgun" }
+
+func (m *Mailgun) Send(ctx context.Context, msg Message) error {
+	message := mailgun.NewMessage(m.from, msg.Subject, "", msg.To)
+	message.SetHtml(msg.HTML)
+	_, _, err := m.client.Send(ctx, message)
+	return err
+}
@@ -0,0 +1,84 @@
+// Package notify delivers availability notifications to subscribers through
+// a configurable provider.
+package notify
+
+import (
+	"backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Message is a single email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+}
+
+// Notifier delivers messages through one provider.
+type Notifier interface {
+	// Name identifies the provider in logs, e.g. "smtp" or "mailgun".
+	Name() string
+
+	// Send delivers msg, returning an error if the provider rejected it.
+	Send(ctx context.Context, msg Message) error
+}
+
+// Pinger is implemented by Notifiers that can check that their provider is
+// reachable without sending anything.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// FromConfig builds the Notifier selected by cfg: "smtp", "mailgun",
+// "mailersend" or "log".
+func FromConfig(cfg config.Notify) (Notifier, error) {
+	switch provider := cfg.Selected(); provider {
+	case "smtp":
+		return SMTPFromConfig(cfg), nil
+	case "mailgun":
+		return MailgunFromConfig(cfg.Mailgun), nil
+	case "mailersend":
+		return NewMailerSend(cfg.MailerSend.APIKey, cfg.MailerSend.From), nil
+	case "log":
+		return Log{}, nil
+	case "":
+		return nil, errors.New("no email provider is configured: set NOTIFIER")
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER %q", provider)
+	}
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultNotifier Notifier
+)
+
+// SetDefault installs n as the Notifier returned by Default.
+func SetDefault(n Notifier) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultNotifier = n
+}
+
+// Default returns the process-wide Notifier, building it with FromConfig on
+// first use unless one has been installed.
+func Default() (Notifier, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultNotifier == nil {
+		cfg, err := config.Default()
+		if err != nil {
+			return nil, err
+		}
+		n, err := FromConfig(cfg.Notify)
+		if err != nil {
+			return nil, err
+		}
+		defaultNotifier = n
+	}
+	return defaultNotifier, nil
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"backend/config"
+	"strings"
+	"testing"
+)
+
+func TestFromConfigSelectsProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Notify
+		want string
+	}{
+		{"explicit log", config.Notify{Provider: "log"}, "log"},
+		{"smtp host", config.Notify{SMTP: config.SMTP{Host: "smtp.example.com"}}, "smtp"},
+		{"gmail account", config.Notify{Gmail: config.Gmail{Email: "me@gmail.com"}}, "smtp"},
+		{"mailgun", config.Notify{Provider: "mailgun"}, "mailgun"},
+	}
+	for _, tt := range tests {
+		n, err := FromConfig(tt.cfg)
+		if err != nil {
+			t.Errorf("%s: FromConfig = %v", tt.name, err)
+			continue
+		}
+		if n.Name() != tt.want {
+			t.Errorf("%s: FromConfig built %q, want %q", tt.name, n.Name(), tt.want)
+		}
+	}
+}
+
+func TestUnsetProviderDoesNotFallBackToLog(t *testing.T) {
+	// A production configuration that only forgot its email settings.
+	cfg := config.Defaults()
+	cfg.Store.PostgresURL = "postgres://db.example.com/classes"
+	cfg.Google.ClientIDs = []string{"client.apps.googleusercontent.com"}
+	cfg.Cron.Secret = "secret"
+
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "NOTIFIER") {
+		t.Errorf("Validate = %v, want it to require NOTIFIER", err)
+	}
+	if n, err := FromConfig(cfg.Notify); err == nil {
+		t.Errorf("FromConfig built %q, want an error", n.Name())
+	}
+}
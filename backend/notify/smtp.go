@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"backend/config"
+	"context"
+	"crypto/tls"
+	"io"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// sendTimeout bounds the whole exchange with the server for one message.
+const sendTimeout = 30 * time.Second
+
+// SMTP sends mail through any SMTP server that supports PLAIN auth over
+// STARTTLS, such as Gmail with an app password.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPFromConfig configures SMTP from cfg.SMTP. For existing deployments, the
+// Gmail settings are still accepted when no SMTP host is set, and imply
+// smtp.gmail.com:587.
+func SMTPFromConfig(cfg config.Notify) *SMTP {
+	if cfg.SMTP.Host == "" && cfg.Gmail.Email != "" {
+		return &SMTP{Host: "smtp.gmail.com", Port: "587", Username: cfg.Gmail.Email, Password: cfg.Gmail.Password, From: cfg.Gmail.Email}
+	}
+
+	s := &SMTP{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
+		From:     cfg.SMTP.From,
+	}
+	if s.Port == "" {
+		s.Port = "587"
+	}
+	if s.From == "" {
+		s.From = s.Username
+	}
+	return s
+}
+
+func (s *SMTP) Name() string { return "smtp" }
+
+// Ping connects to the server and waits for its greeting, without
+// authenticating or sending anything.
+func (s *SMTP) Ping(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, s.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Send delivers msg, giving up once ctx is done or sendTimeout has passed,
+// whichever comes first, so that an unresponsive server cannot stall a run.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, s.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// Unblock the exchange as soon as ctx is cancelled, not just at its deadline.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); ok && s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, s.message(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds the raw MIME message. Line breaks are removed from header
+// values, which may come from user input, so that they cannot add headers.
+func (s *SMTP) message(msg Message) string {
+	return strings.Join([]string{
+		"To: " + headerValue(msg.To),
+		"From: " + headerValue(s.From),
+		"Subject: " + mime.QEncoding.Encode("UTF-8", headerValue(msg.Subject)),
+		"MIME-Version: 1.0",
+		"Content-Type: text/html; charset=\"UTF-8\"",
+		"",
+		msg.HTML,
+	}, "\r\n")
+}
+
+var headerBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+func headerValue(v string) string {
+	return headerBreaks.Replace(v)
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMTPSendStopsWhenContextIsDone(t *testing.T) {
+	// A server that accepts connections but never sends its greeting.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(io.Discard, conn) // until the client hangs up
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	s := &SMTP{Host: host, Port: port, From: "alerts@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := s.Send(ctx, Message{To: "student@example.com", Subject: "Hi", HTML: "<p>Hi</p>"}); err == nil {
+		t.Fatal("Send succeeded against a silent server")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send took %v to give up", elapsed)
+	}
+}
+
+func TestSMTPMessageStripsHeaderLineBreaks(t *testing.T) {
+	s := &SMTP{From: "alerts@example.com"}
+	raw := s.message(Message{
+		To:      "student@example.com",
+		Subject: "Course Update: X\r\nBcc: victim@example.com is now open",
+		HTML:    "<p>Hi</p>",
+	})
+
+	headers, _, _ := strings.Cut(raw, "\r\n\r\n")
+	for _, line := range strings.Split(headers, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Fatalf("message has an injected header: %q", headers)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"backend/checker"
+	"backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// errShutdownStuck is returned by serve when background work is still running
+// a grace period after it was cancelled. It may hold database connections, so
+// the store is left open rather than waited on.
+var errShutdownStuck = errors.New("background work did not stop after cancellation")
+
+// serve runs the HTTP server, and the availability check if it is scheduled
+// in-process, until SIGINT or SIGTERM. It then stops accepting connections
+// and waits up to cfg.Server.ShutdownTimeout for in-flight requests and a
+// running check to finish, before cancelling whatever is left.
+func serve(cfg *config.Config, handler http.Handler) error {
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	// Requests and background work share a context that is only cancelled
+	// once the shutdown deadline has passed, so that a check or an email send
+	// caught by a deploy is not cut off midway.
+	work, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
+	srv := &http.Server{
+		Addr:         cfg.Addr(),
+		Handler:      handler,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		BaseContext:  func(net.Listener) context.Context { return work },
+	}
+
+	// Optionally run the availability check in-process instead of relying on
+	// an external cron hitting /api/cron/check-availability.
+	var workers sync.WaitGroup
+	if cfg.Check.Interval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			checker.Schedule(work, signals.Done(), cfg.Check.Interval, cfg.Check.Jitter)
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Local server running on http://localhost%s", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		err = fmt.Errorf("server error: %w", err)
+	case <-signals.Done():
+		log.Printf("Shutting down, waiting up to %s for in-flight work\n", cfg.Server.ShutdownTimeout)
+	}
+	// A second signal kills the process immediately.
+	stopSignals()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	srv.Shutdown(ctx)
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		log.Println("Shutdown deadline passed; cancelling requests and checks still in progress")
+	}
+
+	// Cancel what did not finish in time. The scheduler is waited for, for at
+	// most another grace period in case it ignores cancellation; any request
+	// still running stops with its context, and closing the store afterwards
+	// waits for it to release its connection.
+	cancelWork()
+	srv.Close()
+	select {
+	case <-drained:
+	case <-time.After(cfg.Server.ShutdownTimeout):
+		return errors.Join(err, errShutdownStuck)
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+// Package cors implements the API's policy for browser calls from other
+// origins, shared by the router and by handlers deployed on their own.
+package cors
+
+import (
+	"backend/config"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy decides which other origins, such as the frontend, may call the
+// API from a browser.
+type Policy struct {
+	// AllowedOrigins lists origins such as "https://example.com". An entry
+	// may use one wildcard followed by a dot ("https://*.example.com"), which
+	// stands for at least one character of the host, and "*" allows every
+	// origin.
+	AllowedOrigins []string
+
+	// AllowedMethods and AllowedHeaders are what a preflight request may ask
+	// for. An AllowedHeaders entry of "*" allows any request header.
+	AllowedMethods []string
+	AllowedHeaders []string
+
+	// AllowCredentials lets browsers send cookies and HTTP authentication.
+	// It requires AllowedOrigins to name origins explicitly.
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// FromConfig builds the policy set in cfg and validates it. Methods
+// are matched case-sensitively by browsers, so they are uppercased.
+func FromConfig(cfg config.CORS) (Policy, error) {
+	p := Policy{
+		AllowedOrigins:   cfg.Origins(),
+		AllowedHeaders:   cfg.AllowedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+	for _, m := range cfg.AllowedMethods {
+		p.AllowedMethods = append(p.AllowedMethods, strings.ToUpper(m))
+	}
+	return p, p.Validate()
+}
+
+// Validate reports settings that browsers would reject or that would be unsafe.
+func (p Policy) Validate() error {
+	if len(p.AllowedOrigins) == 0 {
+		return errors.New("CORS policy allows no origins")
+	}
+	if p.AllowCredentials && slices.Contains(p.AllowedOrigins, "*") {
+		return errors.New(`CORS policy cannot allow credentials from every origin ("*")`)
+	}
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			continue
+		}
+		if strings.Count(o, "*") > 1 {
+			return fmt.Errorf("CORS origin %q has more than one wildcard", o)
+		}
+		// Without the dot, "https://*example.com" would also match
+		// "https://evilexample.com".
+		if _, suffix, ok := strings.Cut(o, "*"); ok && !strings.HasPrefix(suffix, ".") {
+			return fmt.Errorf("CORS origin %q must have a dot after its wildcard, as in https://*.example.com", o)
+		}
+	}
+	if p.MaxAge < 0 {
+		return errors.New("CORS max age must not be negative")
+	}
+	return nil
+}
+
+// Middleware applies the policy. It answers preflight requests itself, before
+// routing, so that they never reach a handler or its authentication. Requests
+// from origins the policy does not allow get no CORS headers, which makes the
+// browser withhold the response.
+func (p Policy) Middleware(next http.Handler) http.Handler {
+	methods := strings.Join(p.AllowedMethods, ", ")
+	headers := strings.Join(p.AllowedHeaders, ", ")
+	anyHeader := slices.Contains(p.AllowedHeaders, "*")
+	maxAge := strconv.Itoa(int(p.MaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !p.allowsOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if slices.Contains(p.AllowedOrigins, "*") {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if p.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !slices.Contains(p.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		if anyHeader {
+			if h := r.Header.Get("Access-Control-Request-Headers"); h != "" {
+				w.Header().Set("Access-Control-Allow-Headers", h)
+			}
+		} else if headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		if p.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowsOrigin reports whether origin matches one of the allowed origins. A
+// wildcard matches one or more characters other than the "/" and ":" that
+// would take it out of the host.
+func (p Policy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		prefix, suffix, ok := strings.Cut(allowed, "*")
+		if !ok || !strings.HasPrefix(suffix, ".") {
+			continue
+		}
+		rest, ok := strings.CutPrefix(origin, prefix)
+		if !ok {
+			continue
+		}
+		label, ok := strings.CutSuffix(rest, suffix)
+		if ok && label != "" && !strings.ContainsAny(label, "/:") {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultPolicy *Policy
+)
+
+// SetDefault installs p as the Policy returned by Default.
+func SetDefault(p Policy) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultPolicy = &p
+}
+
+// Default returns the process-wide Policy, building it with FromConfig on
+// first use unless one has been installed.
+func Default() (Policy, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultPolicy == nil {
+		cfg, err := config.Default()
+		if err != nil {
+			return Policy{}, err
+		}
+		p, err := FromConfig(cfg.CORS)
+		if err != nil {
+			return Policy{}, err
+		}
+		defaultPolicy = &p
+	}
+	return *defaultPolicy, nil
+}
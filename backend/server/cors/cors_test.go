@@ -0,0 +1,123 @@
+package cors
+
+import (
+	"backend/config"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestCORSAllowsOrigin(t *testing.T) {
+	tests := []struct {
+		allowed string
+		origin  string
+		want    bool
+	}{
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "HTTPS://Example.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://example.com", "https://example.com:8443", false},
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://.example.com", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.com", false},
+		{"https://*.example.com", "https://evil.com:1.example.com", false},
+		{"https://*.example.com", "http://app.example.com", false},
+		{"https://app-*.vercel.app", "https://app-git-main.vercel.app", true},
+		{"https://app-*.vercel.app", "https://other.vercel.app", false},
+		{"*", "https://anything.example", true},
+	}
+	for _, tt := range tests {
+		p := Policy{AllowedOrigins: []string{tt.allowed}}
+		if err := p.Validate(); err != nil {
+			t.Fatalf("Validate(%q): %v", tt.allowed, err)
+		}
+		if got := p.allowsOrigin(tt.origin); got != tt.want {
+			t.Errorf("%q allows %q = %v, want %v", tt.allowed, tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORSValidateRejectsUnsafeOrigins(t *testing.T) {
+	for _, origins := range [][]string{
+		nil,
+		{"https://*example.com"},
+		{"https://*"},
+		{"https://*.*.example.com"},
+	} {
+		p := Policy{AllowedOrigins: origins}
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate(%q) = nil, want an error", origins)
+		}
+	}
+
+	p := Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+	if err := p.Validate(); err == nil {
+		t.Error("Validate allowed credentials from every origin")
+	}
+}
+
+func TestPolicyDefaultsToFrontendOrigin(t *testing.T) {
+	tests := []struct {
+		cfg  config.CORS
+		want []string
+	}{
+		{config.CORS{}, []string{"http://localhost:3000"}},
+		{config.CORS{FrontendURL: "https://classes.example.com/app/"}, []string{"https://classes.example.com"}},
+		{config.CORS{FrontendURL: "https://classes.example.com", AllowedOrigins: []string{"*"}}, []string{"*"}},
+	}
+	for _, tt := range tests {
+		p, err := FromConfig(tt.cfg)
+		if err != nil {
+			t.Fatalf("FromConfig(%+v): %v", tt.cfg, err)
+		}
+		if !slices.Equal(p.AllowedOrigins, tt.want) {
+			t.Errorf("FromConfig(%+v) allows %q, want %q", tt.cfg, p.AllowedOrigins, tt.want)
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	p := Policy{
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization"},
+	}
+	handler := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	serve := func(method, origin, requestMethod string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/subscriptions", nil)
+		r.Header.Set("Origin", origin)
+		if requestMethod != "" {
+			r.Header.Set("Access-Control-Request-Method", requestMethod)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, "https://app.example.com", "")
+	if w.Code != http.StatusTeapot || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("allowed request: code %d, Allow-Origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	w = serve(http.MethodGet, "https://evilexample.com", "")
+	if w.Code != http.StatusTeapot || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed request: code %d, Allow-Origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	w = serve(http.MethodOptions, "https://app.example.com", "POST")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" {
+		t.Errorf("preflight: code %d, Allow-Methods %q", w.Code, w.Header().Get("Access-Control-Allow-Methods"))
+	}
+
+	w = serve(http.MethodOptions, "https://app.example.com", "DELETE")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Errorf("preflight for a disallowed method: code %d, Allow-Methods %q", w.Code, w.Header().Get("Access-Control-Allow-Methods"))
+	}
+}
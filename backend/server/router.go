@@ -0,0 +1,123 @@
+// Package server assembles the API handlers into one HTTP router with the
+// middleware every route shares.
+package server
+
+import (
+	"backend/api/courses"
+	checkAvailability "backend/api/cron/check-availability"
+	"backend/api/health"
+	"backend/api/keys"
+	"backend/api/register"
+	"backend/api/sections"
+	"backend/api/subscribe"
+	"backend/api/subscriptions"
+	"backend/api/unsubscribe"
+	"backend/metrics"
+	"backend/server/cors"
+	"backend/server/standalone"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// requestTimeout bounds ordinary API requests.
+	requestTimeout = 30 * time.Second
+
+	// cronTimeout bounds a triggered availability check, which visits every
+	// watched course. It stays below the checker's run lease.
+	cronTimeout = 10 * time.Minute
+)
+
+// NewRouter returns the API's routes, open to browsers as policy allows.
+// Requests with a method a route does not support get 405 Method Not Allowed
+// with an Allow header listing those it does.
+func NewRouter(policy cors.Policy) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(echoRequestID)
+	r.Use(instrument)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(policy.Middleware)
+	r.Use(standalone.Routed)
+
+	r.Get("/healthz", health.Live)
+	r.Get("/readyz", health.Ready)
+	r.Get("/version", health.Version)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(requestTimeout))
+
+		r.Get("/api/courses", courses.Handler)
+		r.Get("/api/sections", sections.Handler)
+		r.Post("/api/register", register.Handler)
+
+		// These handlers authenticate the caller themselves, since they are
+		// also deployed on their own as serverless functions; behind the
+		// router, they leave CORS and method checks to it.
+		r.Get("/api/subscriptions", subscriptions.Handler)
+		r.Post("/api/subscribe", subscribe.Handler)
+		r.Post("/api/unsubscribe", unsubscribe.Handler)
+		r.Get("/api/keys", keys.Handler)
+		r.Post("/api/keys", keys.Handler)
+		r.Delete("/api/keys", keys.Handler)
+	})
+
+	// The check outlasts the server's write timeout, so leave it time to write
+	// its response, including the one sent when cronTimeout expires.
+	r.With(writeDeadline(cronTimeout+time.Minute), middleware.Timeout(cronTimeout)).
+		Get("/api/cron/check-availability", checkAvailability.Handler)
+
+	return r
+}
+
+// writeDeadline moves the connection's write deadline to d from now, for
+// routes that take longer than the server's WriteTimeout allows.
+func writeDeadline(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d)); err != nil {
+				log.Println("Failed to extend write deadline:", err)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// instrument records each request in the API metrics under the pattern of
+// the route that handled it, so that path parameters and unknown paths do not
+// each get their own series. It sits outside the recoverer in order to count
+// the 500 sent for a panic.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		metrics.ObserveRequest(route, r.Method, status, time.Since(start))
+	})
+}
+
+// echoRequestID returns the request's ID in the X-Request-Id response header,
+// so that a client can quote it when reporting a problem.
+func echoRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(middleware.RequestIDHeader, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"backend/metrics"
+	"backend/server/cors"
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// requestCount scrapes the metrics for the number of requests recorded
+// under route, method and code.
+func requestCount(t *testing.T, route, method string, code int) float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	series := `bct_http_requests_total{code="` + strconv.Itoa(code) + `",method="` + method + `",route="` + route + `"} `
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series); ok {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+func TestInstrumentLabelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(instrument)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	matched := requestCount(t, "/items/{id}", "GET", http.StatusNoContent)
+	unmatched := requestCount(t, "unmatched", "GET", http.StatusNotFound)
+	for _, path := range []string{"/items/1", "/items/2?full=true", "/items/3", "/elsewhere/4"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := requestCount(t, "/items/{id}", "GET", http.StatusNoContent) - matched; n != 3 {
+		t.Errorf("recorded %v requests under the route pattern, want 3", n)
+	}
+	if n := requestCount(t, "unmatched", "GET", http.StatusNotFound) - unmatched; n != 1 {
+		t.Errorf("recorded %v unmatched requests, want 1", n)
+	}
+	for _, path := range []string{"/items/1", "/elsewhere/4"} {
+		if n := requestCount(t, path, "GET", http.StatusNoContent) + requestCount(t, path, "GET", http.StatusNotFound); n != 0 {
+			t.Errorf("recorded %v requests under the raw path %s", n, path)
+		}
+	}
+}
+
+func TestRouterRecordsRoutes(t *testing.T) {
+	router := NewRouter(cors.Policy{})
+
+	healthz := requestCount(t, "/healthz", "GET", http.StatusOK)
+	unmatched := requestCount(t, "unmatched", "GET", http.StatusNotFound)
+	for _, path := range []string{"/healthz", "/api/courses/024798"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := requestCount(t, "/healthz", "GET", http.StatusOK) - healthz; n != 1 {
+		t.Errorf("recorded %v requests under /healthz, want 1", n)
+	}
+	if n := requestCount(t, "unmatched", "GET", http.StatusNotFound) - unmatched; n != 1 {
+		t.Errorf("recorded %v requests for an unknown path as unmatched, want 1", n)
+	}
+}
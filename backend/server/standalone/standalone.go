@@ -0,0 +1,54 @@
+// Package standalone adapts the API handlers for deployment on their own, as
+// serverless functions, where none of the router's middleware runs.
+package standalone
+
+import (
+	"backend/server/cors"
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type routedKey struct{}
+
+// Routed marks requests as served by the router, whose own CORS and method
+// handling Handler then leaves to it.
+func Routed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routedKey{}, true)))
+	})
+}
+
+// Handler wraps next so that, served on its own, it applies the process-wide
+// CORS policy and answers requests with a method other than methods with 405
+// Method Not Allowed and an Allow header, as the router would. Preflight
+// requests are answered before they reach next. Behind the router, next is
+// called as it is.
+func Handler(next http.Handler, methods ...string) http.Handler {
+	allow := strings.Join(methods, ", ")
+	checked := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(methods, r.Method) {
+			w.Header().Set("Allow", allow)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if routed, _ := r.Context().Value(routedKey{}).(bool); routed {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy, err := cors.Default()
+		if err != nil {
+			http.Error(w, "Failed to configure CORS", http.StatusInternalServerError)
+			log.Println("CORS config error:", err)
+			return
+		}
+		policy.Middleware(checked).ServeHTTP(w, r)
+	})
+}
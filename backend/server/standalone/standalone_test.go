@@ -0,0 +1,90 @@
+package standalone
+
+import (
+	"backend/server/cors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const frontend = "https://classes.example.com"
+
+func newHandler(t *testing.T) (http.Handler, *int) {
+	t.Helper()
+	cors.SetDefault(cors.Policy{
+		AllowedOrigins: []string{frontend},
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+	})
+
+	calls := 0
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTeapot)
+	}), http.MethodPost)
+	return h, &calls
+}
+
+func serve(h http.Handler, method, requestMethod string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/api/subscribe", nil)
+	r.Header.Set("Origin", frontend)
+	if requestMethod != "" {
+		r.Header.Set("Access-Control-Request-Method", requestMethod)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerAnswersPreflight(t *testing.T) {
+	h, calls := newHandler(t)
+
+	w := serve(h, http.MethodOptions, http.MethodPost)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want 204", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != frontend {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, frontend)
+	}
+	if *calls != 0 {
+		t.Error("preflight reached the handler")
+	}
+}
+
+func TestHandlerRejectsOtherMethods(t *testing.T) {
+	h, calls := newHandler(t)
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		w := serve(h, method, "")
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+			t.Errorf("%s: status %d, Allow %q; want 405 with Allow POST", method, w.Code, w.Header().Get("Allow"))
+		}
+	}
+	if *calls != 0 {
+		t.Error("a disallowed method reached the handler")
+	}
+}
+
+func TestHandlerServesAllowedMethod(t *testing.T) {
+	h, calls := newHandler(t)
+
+	w := serve(h, http.MethodPost, "")
+	if w.Code != http.StatusTeapot || *calls != 1 {
+		t.Errorf("POST: status %d after %d calls, want the handler's 418", w.Code, *calls)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != frontend {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, frontend)
+	}
+}
+
+func TestHandlerBehindRouterLeavesChecksToIt(t *testing.T) {
+	h, calls := newHandler(t)
+
+	w := serve(Routed(h), http.MethodGet, "")
+	if w.Code != http.StatusTeapot || *calls != 1 {
+		t.Errorf("routed GET: status %d after %d calls, want it passed through", w.Code, *calls)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("routed request got CORS headers from the handler: %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+package store
+
+import "time"
+
+// APIKey is a row in the api_keys table. Only a hash of the key is stored;
+// Prefix keeps its first few characters so that users can tell keys apart.
+type APIKey struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Prefix     string
+	Hash       []byte
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"backend/config"
+	"context"
+	"sync"
+)
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore Store
+)
+
+// Open returns the Store selected by cfg.Driver: "memory" for an in-process
+// store, or Postgres at cfg.PostgresURL otherwise.
+func Open(ctx context.Context, cfg config.Store) (Store, error) {
+	if cfg.Driver == "memory" {
+		return NewMemory(), nil
+	}
+	return NewPostgres(ctx, cfg.PostgresURL)
+}
+
+// SetDefault installs s as the Store returned by Default. main.go calls it
+// once at startup; tests can use it to swap in a different implementation.
+func SetDefault(s Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = s
+}
+
+// Default returns the process-wide Store. If none has been installed, one is
+// opened with Open and reused by later calls, so serverless invocations of a
+// warm instance share a single pool.
+func Default() (Store, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultStore == nil {
+		cfg, err := config.Default()
+		if err != nil {
+			return nil, err
+		}
+		s, err := Open(context.Background(), cfg.Store)
+		if err != nil {
+			return nil, err
+		}
+		defaultStore = s
+	}
+	return defaultStore, nil
+}
@@ -0,0 +1,444 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Store for tests and offline development. It mirrors
+// the constraints and upsert semantics of the Postgres schema.
+type Memory struct {
+	mu           sync.Mutex
+	nextUserID   int64
+	users        map[string]*User // keyed by email
+	subs         []*memorySubscription
+	availability map[courseKey]CourseAvailability
+	sections     map[courseKey]map[int]SectionAvailability
+	outbox       []*Notification
+	nextNotifyID int64
+	leases       map[string]memoryLease
+	apiKeys      []*APIKey
+	nextKeyID    int64
+}
+
+type memoryLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+type courseKey struct {
+	courseID          string
+	courseSubjectCode string
+}
+
+type memorySubscription struct {
+	userID int64
+	Subscription
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		users:        make(map[string]*User),
+		availability: make(map[courseKey]CourseAvailability),
+		sections:     make(map[courseKey]map[int]SectionAvailability),
+		leases:       make(map[string]memoryLease),
+	}
+}
+
+func (m *Memory) Close() {}
+
+func (m *Memory) Ping(ctx context.Context) error { return nil }
+
+func (m *Memory) UpsertUser(ctx context.Context, u User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := m.users[u.Email]; ok {
+		existing.GoogleSub = u.GoogleSub
+		existing.Name = u.Name
+		existing.Image = u.Image
+		existing.LastLoggedIn = now
+		return nil
+	}
+
+	m.nextUserID++
+	u.ID = m.nextUserID
+	u.CreatedAt = now
+	u.LastLoggedIn = now
+	m.users[u.Email] = &u
+	return nil
+}
+
+func (m *Memory) GetUser(ctx context.Context, email string) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[email]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return *u, nil
+}
+
+func (m *Memory) AddSubscription(ctx context.Context, s Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// subscriptions.user_id is NOT NULL, so an unknown email fails the insert.
+	user, ok := m.users[s.UserEmail]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if existing := m.findSubscription(user.ID, s.CourseID, s.CourseSubjectCode, s.ClassNumber); existing != nil {
+		existing.UserFullName = s.UserFullName
+		existing.CourseName = s.CourseName
+		existing.Credits = s.Credits
+		existing.Title = s.Title
+		existing.NotifyOn = s.NotifyOn
+		existing.MinOpenSeats = s.MinOpenSeats
+		existing.WaitlistBelow = s.WaitlistBelow
+		return nil
+	}
+
+	s.CreatedAt = time.Now()
+	m.subs = append(m.subs, &memorySubscription{userID: user.ID, Subscription: s})
+	return nil
+}
+
+func (m *Memory) RemoveSubscription(ctx context.Context, userEmail, courseID, courseSubjectCode string, classNumber int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if user, ok := m.users[userEmail]; ok {
+		kept := m.subs[:0]
+		for _, s := range m.subs {
+			if s.userID == user.ID && s.CourseID == courseID && s.CourseSubjectCode == courseSubjectCode &&
+				(classNumber == 0 || s.ClassNumber == classNumber) {
+				continue
+			}
+			kept = append(kept, s)
+		}
+		m.subs = kept
+	}
+
+	// Drop the availability records once nobody is watching the course.
+	for _, s := range m.subs {
+		if s.CourseID == courseID && s.CourseSubjectCode == courseSubjectCode {
+			return nil
+		}
+	}
+	delete(m.availability, courseKey{courseID, courseSubjectCode})
+	delete(m.sections, courseKey{courseID, courseSubjectCode})
+	return nil
+}
+
+func (m *Memory) ListSubscriptions(ctx context.Context, userEmail string) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[userEmail]
+	if !ok {
+		return nil, nil
+	}
+
+	var subs []Subscription
+	for _, s := range m.subs {
+		if s.userID == user.ID {
+			subs = append(subs, s.Subscription)
+		}
+	}
+	return subs, nil
+}
+
+func (m *Memory) ListWatchedCourses(ctx context.Context) ([]WatchedCourse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Subscribers may have saved different names for a course; like the
+	// Postgres query, keep the least.
+	index := make(map[courseKey]int)
+	var courses []WatchedCourse
+	for _, s := range m.subs {
+		key := courseKey{s.CourseID, s.CourseSubjectCode}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(courses)
+			courses = append(courses, WatchedCourse{
+				CourseID:          s.CourseID,
+				CourseSubjectCode: s.CourseSubjectCode,
+				CourseName:        s.CourseName,
+			})
+		} else if s.CourseName < courses[i].CourseName {
+			courses[i].CourseName = s.CourseName
+		}
+	}
+	return courses, nil
+}
+
+func (m *Memory) ListCourseSubscriptions(ctx context.Context, courseID, courseSubjectCode string) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var subs []Subscription
+	for _, s := range m.subs {
+		if s.CourseID == courseID && s.CourseSubjectCode == courseSubjectCode {
+			subs = append(subs, s.Subscription)
+		}
+	}
+	return subs, nil
+}
+
+func (m *Memory) GetCourseAvailability(ctx context.Context, courseID, courseSubjectCode string) (CourseAvailability, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.availability[courseKey{courseID, courseSubjectCode}]
+	if !ok {
+		return CourseAvailability{}, ErrNotFound
+	}
+	return a, nil
+}
+
+func (m *Memory) SetCourseAvailability(ctx context.Context, a CourseAvailability) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setCourseAvailability(a)
+	return nil
+}
+
+func (m *Memory) setCourseAvailability(a CourseAvailability) {
+	key := courseKey{a.CourseID, a.CourseSubjectCode}
+	a.LastChecked = time.Now()
+
+	// Like the ON CONFLICT clause, an existing row keeps its original course_name.
+	a.Version = 1
+	if existing, ok := m.availability[key]; ok {
+		a.CourseName = existing.CourseName
+		a.Version = existing.Version + 1
+	}
+	m.availability[key] = a
+}
+
+func (m *Memory) ListSectionAvailability(ctx context.Context, courseID, courseSubjectCode string) ([]SectionAvailability, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sections []SectionAvailability
+	for _, a := range m.sections[courseKey{courseID, courseSubjectCode}] {
+		sections = append(sections, a)
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].ClassNumber < sections[j].ClassNumber })
+	return sections, nil
+}
+
+func (m *Memory) SetSectionAvailability(ctx context.Context, a SectionAvailability) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setSectionAvailability(a)
+	return nil
+}
+
+func (m *Memory) setSectionAvailability(a SectionAvailability) {
+	key := courseKey{a.CourseID, a.CourseSubjectCode}
+	if m.sections[key] == nil {
+		m.sections[key] = make(map[int]SectionAvailability)
+	}
+	a.LastChecked = time.Now()
+	m.sections[key][a.ClassNumber] = a
+}
+
+func (m *Memory) RecordCheck(ctx context.Context, course CourseAvailability, sections []SectionAvailability, outbox []Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.availability[courseKey{course.CourseID, course.CourseSubjectCode}].Version != course.Version {
+		return ErrConflict
+	}
+	for _, a := range sections {
+		m.setSectionAvailability(a)
+	}
+	m.setCourseAvailability(course)
+
+	now := time.Now()
+	for _, n := range outbox {
+		m.nextNotifyID++
+		n.ID = m.nextNotifyID
+		n.Status = NotificationPending
+		n.Attempts = 0
+		n.NextAttemptAt = now
+		n.CreatedAt = now
+		m.outbox = append(m.outbox, &n)
+	}
+	return nil
+}
+
+func (m *Memory) CreateAPIKey(ctx context.Context, k APIKey) (APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.apiKeys {
+		if bytes.Equal(existing.Hash, k.Hash) {
+			return APIKey{}, errors.New("store: duplicate API key hash")
+		}
+	}
+	m.nextKeyID++
+	k.ID = m.nextKeyID
+	k.CreatedAt = time.Now()
+	k.LastUsedAt = nil
+	k.RevokedAt = nil
+	k.Scopes = slices.Clone(k.Scopes)
+	m.apiKeys = append(m.apiKeys, &k)
+	return k, nil
+}
+
+func (m *Memory) ListAPIKeys(ctx context.Context, userID int64) ([]APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []APIKey
+	for i := len(m.apiKeys) - 1; i >= 0; i-- {
+		if k := m.apiKeys[i]; k.UserID == userID && k.RevokedAt == nil {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *Memory) RevokeAPIKey(ctx context.Context, userID, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.apiKeys {
+		if k.ID == id && k.UserID == userID && k.RevokedAt == nil {
+			now := time.Now()
+			k.RevokedAt = &now
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *Memory) UseAPIKey(ctx context.Context, hash []byte) (APIKey, User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.apiKeys {
+		if !bytes.Equal(k.Hash, hash) || k.RevokedAt != nil {
+			continue
+		}
+		for _, u := range m.users {
+			if u.ID == k.UserID {
+				now := time.Now()
+				k.LastUsedAt = &now
+				return *k, *u, nil
+			}
+		}
+	}
+	return APIKey{}, User{}, ErrNotFound
+}
+
+func (m *Memory) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if l, ok := m.leases[name]; ok && l.holder != holder && l.expiresAt.After(now) {
+		return false, nil
+	}
+	m.leases[name] = memoryLease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (m *Memory) ReleaseLease(ctx context.Context, name, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.leases[name]; ok && l.holder == holder {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+func (m *Memory) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var claimed []Notification
+	for _, n := range m.outbox {
+		if len(claimed) >= limit {
+			break
+		}
+		if n.Status != NotificationPending || n.NextAttemptAt.After(now) {
+			continue
+		}
+		n.NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, *n)
+	}
+	return claimed, nil
+}
+
+func (m *Memory) MarkNotificationSent(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findNotification(id)
+	if n == nil {
+		return ErrNotFound
+	}
+	now := time.Now()
+	n.Status = NotificationSent
+	n.Attempts++
+	n.LastError = ""
+	n.LastAttemptAt = &now
+	n.SentAt = &now
+	return nil
+}
+
+func (m *Memory) MarkNotificationFailed(ctx context.Context, id int64, deliveryErr string, nextAttempt time.Time, dead bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findNotification(id)
+	if n == nil {
+		return ErrNotFound
+	}
+	now := time.Now()
+	n.Status = NotificationPending
+	if dead {
+		n.Status = NotificationDead
+	}
+	n.Attempts++
+	n.LastError = deliveryErr
+	n.LastAttemptAt = &now
+	n.NextAttemptAt = nextAttempt
+	return nil
+}
+
+func (m *Memory) findNotification(id int64) *Notification {
+	for _, n := range m.outbox {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+func (m *Memory) findSubscription(userID int64, courseID, courseSubjectCode string, classNumber int) *memorySubscription {
+	for _, s := range m.subs {
+		if s.userID == userID && s.CourseID == courseID && s.CourseSubjectCode == courseSubjectCode && s.ClassNumber == classNumber {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemoryLease(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+
+	acquire := func(holder string, ttl time.Duration) bool {
+		t.Helper()
+		ok, err := m.AcquireLease(ctx, "run", holder, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	if !acquire("a", time.Minute) {
+		t.Fatal("free lease was not acquired")
+	}
+	if acquire("b", time.Minute) {
+		t.Error("held lease was acquired by another holder")
+	}
+	if !acquire("a", time.Minute) {
+		t.Error("holder could not renew its lease")
+	}
+
+	// Releasing is a no-op for anyone but the holder.
+	if err := m.ReleaseLease(ctx, "run", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if acquire("b", time.Minute) {
+		t.Error("lease was released by a holder that did not have it")
+	}
+	if err := m.ReleaseLease(ctx, "run", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if !acquire("b", 10*time.Millisecond) {
+		t.Fatal("released lease was not acquired")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if !acquire("a", time.Minute) {
+		t.Error("expired lease was not acquired")
+	}
+}
+
+func TestMemoryRecordCheckConflict(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+
+	course := CourseAvailability{CourseID: "024798", CourseSubjectCode: "266", CourseName: "COMP SCI 300", Status: StatusClosed}
+	outbox := []Notification{{Recipient: "student@example.com", Subject: "Update", CourseID: "024798", CourseSubjectCode: "266"}}
+
+	// Two runs read the course before either records it.
+	if err := m.RecordCheck(ctx, course, nil, outbox); err != nil {
+		t.Fatalf("first RecordCheck: %v", err)
+	}
+	if err := m.RecordCheck(ctx, course, nil, outbox); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second RecordCheck = %v, want ErrConflict", err)
+	}
+
+	got, err := m.GetCourseAvailability(ctx, course.CourseID, course.CourseSubjectCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 1 {
+		t.Errorf("version = %d, want 1", got.Version)
+	}
+	claimed, err := m.ClaimNotifications(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 {
+		t.Errorf("queued %d notifications, want only the first check's 1", len(claimed))
+	}
+
+	// A check made against the current version succeeds.
+	course.Version = got.Version
+	course.Status = StatusOpen
+	if err := m.RecordCheck(ctx, course, nil, nil); err != nil {
+		t.Errorf("RecordCheck at the current version: %v", err)
+	}
+}
+
+// newMemoryWithUsers returns a store with the given emails registered.
+func newMemoryWithUsers(t *testing.T, emails ...string) *Memory {
+	t.Helper()
+	m := NewMemory()
+	for _, email := range emails {
+		if err := m.UpsertUser(context.Background(), User{Email: email, Name: "Student"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestMemoryUpsertUser(t *testing.T) {
+	m := newMemoryWithUsers(t, "student@example.com")
+	ctx := context.Background()
+	first, err := m.GetUser(ctx, "student@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UpsertUser(ctx, User{Email: "student@example.com", GoogleSub: "123", Name: "Renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.GetUser(ctx, "student@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID || !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("upsert replaced the user: %+v, then %+v", first, second)
+	}
+	if second.Name != "Renamed" || second.GoogleSub != "123" {
+		t.Errorf("upsert kept %+v, want the new name and google_sub", second)
+	}
+
+	if _, err := m.GetUser(ctx, "stranger@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetUser of an unknown email = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryAddSubscriptionUpserts(t *testing.T) {
+	m := newMemoryWithUsers(t, "student@example.com")
+	ctx := context.Background()
+
+	sub := Subscription{UserEmail: "student@example.com", CourseID: "024798", CourseSubjectCode: "266", CourseName: "COMP SCI 300"}
+	if err := m.AddSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	sub.CourseName = "COMP SCI 300 (renamed)"
+	sub.NotifyOn = []Status{StatusOpen}
+	if err := m.AddSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	section := sub
+	section.ClassNumber = 1001
+	if err := m.AddSubscription(ctx, section); err != nil {
+		t.Fatal(err)
+	}
+
+	subs, err := m.ListSubscriptions(ctx, "student@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 2 {
+		t.Fatalf("listed %d subscriptions, want the course and one section", len(subs))
+	}
+	if subs[0].CourseName != "COMP SCI 300 (renamed)" || !subs[0].Wants(StatusOpen) || subs[0].Wants(StatusClosed) {
+		t.Errorf("resubscribing did not update the details: %+v", subs[0])
+	}
+}
+
+func TestMemoryAddSubscriptionRequiresRegisteredUser(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+
+	sub := Subscription{UserEmail: "stranger@example.com", CourseID: "024798", CourseSubjectCode: "266"}
+	if err := m.AddSubscription(ctx, sub); err == nil {
+		t.Fatal("AddSubscription for an unregistered user succeeded")
+	}
+	if courses, _ := m.ListWatchedCourses(ctx); len(courses) != 0 {
+		t.Errorf("unregistered user's subscription is watched: %+v", courses)
+	}
+}
+
+func TestMemoryRemoveLastSubscriptionDropsAvailability(t *testing.T) {
+	m := newMemoryWithUsers(t, "a@example.com", "b@example.com")
+	ctx := context.Background()
+
+	for _, sub := range []Subscription{
+		{UserEmail: "a@example.com", ClassNumber: 1001},
+		{UserEmail: "a@example.com", ClassNumber: 1002},
+		{UserEmail: "b@example.com"},
+	} {
+		sub.CourseID, sub.CourseSubjectCode = "024798", "266"
+		if err := m.AddSubscription(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := m.RecordCheck(ctx,
+		CourseAvailability{CourseID: "024798", CourseSubjectCode: "266", Status: StatusOpen},
+		[]SectionAvailability{{CourseID: "024798", CourseSubjectCode: "266", ClassNumber: 1001, Status: StatusOpen}},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Class number zero removes every subscription to the course.
+	if err := m.RemoveSubscription(ctx, "a@example.com", "024798", "266", 0); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := m.ListSubscriptions(ctx, "a@example.com"); len(subs) != 0 {
+		t.Errorf("a still has %d subscriptions", len(subs))
+	}
+	if _, err := m.GetCourseAvailability(ctx, "024798", "266"); err != nil {
+		t.Errorf("availability dropped while b still subscribes: %v", err)
+	}
+
+	if err := m.RemoveSubscription(ctx, "b@example.com", "024798", "266", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetCourseAvailability(ctx, "024798", "266"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCourseAvailability after the last unsubscribe = %v, want ErrNotFound", err)
+	}
+	if sections, _ := m.ListSectionAvailability(ctx, "024798", "266"); len(sections) != 0 {
+		t.Errorf("%d section records left after the last unsubscribe", len(sections))
+	}
+	if courses, _ := m.ListWatchedCourses(ctx); len(courses) != 0 {
+		t.Errorf("still watching %+v", courses)
+	}
+}
+
+func TestMemoryListWatchedCoursesDedup(t *testing.T) {
+	m := newMemoryWithUsers(t, "a@example.com", "b@example.com")
+	ctx := context.Background()
+
+	for _, sub := range []Subscription{
+		{UserEmail: "a@example.com", CourseID: "024798", CourseSubjectCode: "266", CourseName: "CS 300"},
+		{UserEmail: "a@example.com", CourseID: "024798", CourseSubjectCode: "266", CourseName: "CS 300", ClassNumber: 1001},
+		// A name saved differently is still the same course; the least is kept.
+		{UserEmail: "b@example.com", CourseID: "024798", CourseSubjectCode: "266", CourseName: "COMP SCI 300"},
+		{UserEmail: "b@example.com", CourseID: "024798", CourseSubjectCode: "266", CourseName: "COMP SCI 300 (old)", ClassNumber: 1002},
+		// The same course ID under another subject is another course.
+		{UserEmail: "b@example.com", CourseID: "024798", CourseSubjectCode: "268", CourseName: "E C E 300"},
+	} {
+		if err := m.AddSubscription(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	courses, err := m.ListWatchedCourses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WatchedCourse{
+		{CourseID: "024798", CourseSubjectCode: "266", CourseName: "COMP SCI 300"},
+		{CourseID: "024798", CourseSubjectCode: "268", CourseName: "E C E 300"},
+	}
+	if !slices.Equal(courses, want) {
+		t.Errorf("ListWatchedCourses = %+v, want %+v", courses, want)
+	}
+}
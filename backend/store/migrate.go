@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change. Files are named
+// NNNN_description.up.sql and NNNN_description.down.sql.
+type Migration struct {
+	Version   int
+	Name      string
+	Up        string
+	Down      string
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles)
+}
+
+// loadMigrations reads the migrations in the migrations directory of fsys.
+// Every version must have both an up and a down file under one name, and
+// versions must run from 1 without gaps, so that a misnumbered file is caught
+// before it is applied out of order or not at all.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		prefix, rest, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: missing version prefix", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", name, err)
+		}
+
+		body, err := fs.ReadFile(fsys, "migrations/"+name)
+		if err != nil {
+			return nil, err
+		}
+
+		migrationName := strings.TrimSuffix(rest, "."+direction+".sql")
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: migrationName}
+			byVersion[version] = m
+		} else if m.Name != migrationName {
+			return nil, fmt.Errorf("migration %s: version %04d is already used by %04d_%s", name, version, version, m.Name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d_%s: expected version %04d, versions must not skip numbers", m.Version, m.Name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// MigrationStatus returns every embedded migration with AppliedAt set for
+// those already recorded in schema_migrations.
+func (p *Postgres) MigrationStatus(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.pool.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		if at, ok := applied[migrations[i].Version]; ok {
+			migrations[i].AppliedAt = &at
+		}
+	}
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in order and returns the ones it ran.
+func (p *Postgres) MigrateUp(ctx context.Context) ([]Migration, error) {
+	migrations, err := p.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, m := range migrations {
+		if m.AppliedAt != nil {
+			continue
+		}
+		err := p.inTx(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// MigrateDown reverts the most recent steps applied migrations and returns
+// the ones it ran.
+func (p *Postgres) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := p.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for i := len(migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+		m := migrations[i]
+		if m.AppliedAt == nil {
+			continue
+		}
+		err := p.inTx(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+func (p *Postgres) ensureMigrationsTable(ctx context.Context) error {
+	_, err := p.pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+		  version    INTEGER PRIMARY KEY,
+		  name       TEXT NOT NULL,
+		  applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	return err
+}
+
+func (p *Postgres) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d", i, m.Version)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %04d_%s is missing its up or down SQL", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	files := func(names ...string) fstest.MapFS {
+		fsys := fstest.MapFS{}
+		for _, name := range names {
+			fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("-- " + name)}
+		}
+		return fsys
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{"ordered by version", files(
+			"0010_ten.up.sql", "0010_ten.down.sql",
+			"0002_two.up.sql", "0002_two.down.sql",
+			"0001_one.up.sql", "0001_one.down.sql",
+			"0003_three.up.sql", "0003_three.down.sql",
+			"0004_four.up.sql", "0004_four.down.sql",
+			"0005_five.up.sql", "0005_five.down.sql",
+			"0006_six.up.sql", "0006_six.down.sql",
+			"0007_seven.up.sql", "0007_seven.down.sql",
+			"0008_eight.up.sql", "0008_eight.down.sql",
+			"0009_nine.up.sql", "0009_nine.down.sql",
+			"README.md",
+		), ""},
+		{"missing down", files("0001_one.up.sql", "0001_one.down.sql", "0002_two.up.sql"), "needs both up and down"},
+		{"missing up", files("0001_one.down.sql"), "needs both up and down"},
+		{"gap", files("0001_one.up.sql", "0001_one.down.sql", "0003_three.up.sql", "0003_three.down.sql"), "expected version 0002"},
+		{"not starting at one", files("0002_two.up.sql", "0002_two.down.sql"), "expected version 0001"},
+		{"duplicate version", files(
+			"0001_one.up.sql", "0001_one.down.sql",
+			"0001_other.up.sql", "0001_other.down.sql",
+		), "already used"},
+		{"no version", files("one.up.sql", "one.down.sql"), "missing version prefix"},
+		{"bad version", files("first_one.up.sql", "first_one.down.sql"), "invalid version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.fsys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMigrations = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, m := range migrations {
+				file := fmt.Sprintf("%04d_%s", m.Version, m.Name)
+				if m.Version != i+1 || m.Up != "-- "+file+".up.sql" || m.Down != "-- "+file+".down.sql" {
+					t.Errorf("migration %d = %+v", i, m)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+-- IF NOT EXISTS lets databases created before migrations existed adopt this baseline.
+CREATE TABLE IF NOT EXISTS users (
+    id             BIGSERIAL PRIMARY KEY,
+    email          TEXT        NOT NULL UNIQUE,
+    google_sub     TEXT        NOT NULL DEFAULT '',
+    name           TEXT        NOT NULL DEFAULT '',
+    image          TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_logged_in TIMESTAMPTZ NOT NULL DEFAULT now()
+);
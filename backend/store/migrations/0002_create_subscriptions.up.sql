@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id                  BIGSERIAL PRIMARY KEY,
+    user_id             BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_email          TEXT        NOT NULL,
+    user_fullname       TEXT        NOT NULL DEFAULT '',
+    course_id           TEXT        NOT NULL,
+    course_name         TEXT        NOT NULL,
+    course_subject_code TEXT        NOT NULL,
+    credits             INTEGER     NOT NULL DEFAULT 0,
+    title               TEXT        NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, course_id, course_subject_code)
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_course_idx
+    ON subscriptions (course_id, course_subject_code);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS course_availability (
+    course_id           TEXT        NOT NULL,
+    course_subject_code TEXT        NOT NULL,
+    course_name         TEXT        NOT NULL,
+    course_status       TEXT        NOT NULL,
+    last_checked        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (course_id, course_subject_code)
+);
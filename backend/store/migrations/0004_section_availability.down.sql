@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS section_availability;
+
+DELETE FROM subscriptions WHERE class_number <> 0;
+DROP INDEX IF EXISTS subscriptions_user_course_section_key;
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_user_id_course_id_course_subject_code_key
+    UNIQUE (user_id, course_id, course_subject_code);
+ALTER TABLE subscriptions DROP COLUMN class_number;
@@ -0,0 +1,19 @@
+-- class_number = 0 means the subscription watches the whole course.
+ALTER TABLE subscriptions ADD COLUMN class_number INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_id_course_id_course_subject_code_key;
+CREATE UNIQUE INDEX subscriptions_user_course_section_key
+    ON subscriptions (user_id, course_id, course_subject_code, class_number);
+
+CREATE TABLE section_availability (
+    course_id           TEXT        NOT NULL,
+    course_subject_code TEXT        NOT NULL,
+    class_number        INTEGER     NOT NULL,
+    sections            TEXT        NOT NULL DEFAULT '',
+    status              TEXT        NOT NULL,
+    open_seats          INTEGER     NOT NULL DEFAULT 0,
+    capacity            INTEGER     NOT NULL DEFAULT 0,
+    waitlist_size       INTEGER     NOT NULL DEFAULT 0,
+    last_checked        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (course_id, course_subject_code, class_number)
+);
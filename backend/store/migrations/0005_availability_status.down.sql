@@ -0,0 +1,7 @@
+ALTER TABLE subscriptions DROP COLUMN notify_on;
+
+ALTER TABLE section_availability DROP CONSTRAINT IF EXISTS section_availability_status_check;
+ALTER TABLE course_availability DROP CONSTRAINT IF EXISTS course_availability_status_check;
+
+UPDATE course_availability SET course_status = 'open' WHERE course_status = 'waitlisted';
+UPDATE course_availability SET course_status = 'full' WHERE course_status IN ('closed', 'not_offered');
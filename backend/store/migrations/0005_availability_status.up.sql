@@ -0,0 +1,13 @@
+-- Course status used to be just "open" or "full".
+UPDATE course_availability SET course_status = 'closed' WHERE course_status = 'full';
+
+ALTER TABLE course_availability
+    ADD CONSTRAINT course_availability_status_check
+    CHECK (course_status IN ('open', 'waitlisted', 'closed', 'not_offered'));
+
+ALTER TABLE section_availability
+    ADD CONSTRAINT section_availability_status_check
+    CHECK (status IN ('open', 'waitlisted', 'closed', 'not_offered'));
+
+-- Statuses a subscriber wants to hear about; empty means every change.
+ALTER TABLE subscriptions ADD COLUMN notify_on TEXT[] NOT NULL DEFAULT '{}';
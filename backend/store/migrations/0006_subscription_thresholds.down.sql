@@ -0,0 +1,7 @@
+ALTER TABLE course_availability
+    DROP COLUMN waitlist_size,
+    DROP COLUMN open_seats;
+
+ALTER TABLE subscriptions
+    DROP COLUMN waitlist_below,
+    DROP COLUMN min_open_seats;
@@ -0,0 +1,9 @@
+-- Optional conditions: notify once open_seats >= min_open_seats, or once a
+-- waitlisted course's waitlist is shorter than waitlist_below.
+ALTER TABLE subscriptions
+    ADD COLUMN min_open_seats INTEGER CHECK (min_open_seats > 0),
+    ADD COLUMN waitlist_below INTEGER CHECK (waitlist_below > 0);
+
+ALTER TABLE course_availability
+    ADD COLUMN open_seats    INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN waitlist_size INTEGER NOT NULL DEFAULT 0;
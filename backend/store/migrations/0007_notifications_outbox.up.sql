@@ -0,0 +1,20 @@
+CREATE TABLE notifications (
+    id                  BIGSERIAL PRIMARY KEY,
+    recipient           TEXT        NOT NULL,
+    subject             TEXT        NOT NULL,
+    html                TEXT        NOT NULL,
+    course_id           TEXT        NOT NULL DEFAULT '',
+    course_subject_code TEXT        NOT NULL DEFAULT '',
+    status              TEXT        NOT NULL DEFAULT 'pending'
+                        CHECK (status IN ('pending', 'sent', 'dead')),
+    attempts            INTEGER     NOT NULL DEFAULT 0,
+    last_error          TEXT        NOT NULL DEFAULT '',
+    next_attempt_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_attempt_at     TIMESTAMPTZ,
+    sent_at             TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX notifications_due_idx
+    ON notifications (next_attempt_at)
+    WHERE status = 'pending';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS leases;
+
+ALTER TABLE course_availability
+    DROP COLUMN IF EXISTS version;
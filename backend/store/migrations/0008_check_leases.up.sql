@@ -0,0 +1,8 @@
+ALTER TABLE course_availability
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+CREATE TABLE leases (
+    name       TEXT PRIMARY KEY,
+    holder     TEXT        NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT        NOT NULL DEFAULT '',
+    prefix       TEXT        NOT NULL,
+    key_hash     BYTEA       NOT NULL UNIQUE,
+    scopes       TEXT[]      NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX api_keys_user_idx ON api_keys (user_id);
@@ -0,0 +1,31 @@
+package store
+
+import "time"
+
+// NotificationStatus is the delivery state of an outbox entry.
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending" // waiting to be (re)delivered
+	NotificationSent    NotificationStatus = "sent"    // delivered successfully
+	NotificationDead    NotificationStatus = "dead"    // gave up after too many failures
+)
+
+// Notification is a row in the notifications outbox. Entries are written in
+// the same transaction as the availability change that caused them and are
+// delivered afterwards by a dispatcher.
+type Notification struct {
+	ID                int64
+	Recipient         string
+	Subject           string
+	HTML              string
+	CourseID          string
+	CourseSubjectCode string
+	Status            NotificationStatus
+	Attempts          int
+	LastError         string
+	NextAttemptAt     time.Time
+	LastAttemptAt     *time.Time
+	SentAt            *time.Time
+	CreatedAt         time.Time
+}
@@ -0,0 +1,610 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres is a Store backed by a long-lived pgx connection pool.
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgres opens a connection pool to the database at dbURL.
+func NewPostgres(ctx context.Context, dbURL string) (*Postgres, error) {
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Postgres{pool: pool}, nil
+}
+
+// Pool exposes the underlying connection pool.
+func (p *Postgres) Pool() *pgxpool.Pool {
+	return p.pool
+}
+
+func (p *Postgres) Close() {
+	p.pool.Close()
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+func (p *Postgres) UpsertUser(ctx context.Context, u User) error {
+	now := time.Now()
+
+	// 'created_at' remains the original insertion time; each new sign-in
+	// updates last_logged_in for that user row.
+	query := `
+	  INSERT INTO users (email, google_sub, name, image, created_at, last_logged_in)
+	  VALUES ($1, $2, $3, $4, $5, $5)
+	  ON CONFLICT (email)
+	  DO UPDATE SET
+	    google_sub      = EXCLUDED.google_sub,
+	    name            = EXCLUDED.name,
+	    image           = EXCLUDED.image,
+	    last_logged_in  = EXCLUDED.last_logged_in
+	`
+	_, err := p.pool.Exec(ctx, query, u.Email, u.GoogleSub, u.Name, u.Image, now)
+	return err
+}
+
+func (p *Postgres) GetUser(ctx context.Context, email string) (User, error) {
+	query := `
+		SELECT id, email, google_sub, name, image, created_at, last_logged_in
+		FROM users
+		WHERE email = $1
+	`
+	var u User
+	err := p.pool.QueryRow(ctx, query, email).Scan(
+		&u.ID,
+		&u.Email,
+		&u.GoogleSub,
+		&u.Name,
+		&u.Image,
+		&u.CreatedAt,
+		&u.LastLoggedIn,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return u, err
+}
+
+func (p *Postgres) AddSubscription(ctx context.Context, s Subscription) error {
+	now := time.Now()
+
+	query := `
+	INSERT INTO subscriptions (
+	  user_id, user_email, user_fullname, course_id,
+	  course_name, course_subject_code, created_at,
+	  credits, title, class_number, notify_on,
+	  min_open_seats, waitlist_below
+	)
+	VALUES (
+	  (SELECT id FROM users WHERE email=$1),
+	  $1, $2, $3,
+	  $4, $5, $6,
+	  $7, $8, $9, $10,
+	  $11, $12
+	)
+	ON CONFLICT (user_id, course_id, course_subject_code, class_number)
+	DO UPDATE SET
+	  user_fullname = EXCLUDED.user_fullname,
+	  course_name = EXCLUDED.course_name,
+	  credits = EXCLUDED.credits,
+	  title = EXCLUDED.title,
+	  notify_on = EXCLUDED.notify_on,
+	  min_open_seats = EXCLUDED.min_open_seats,
+	  waitlist_below = EXCLUDED.waitlist_below
+	`
+	_, err := p.pool.Exec(ctx, query,
+		s.UserEmail,               // $1
+		s.UserFullName,            // $2
+		s.CourseID,                // $3
+		s.CourseName,              // $4
+		s.CourseSubjectCode,       // $5
+		now,                       // $6 (for created_at)
+		s.Credits,                 // $7
+		s.Title,                   // $8
+		s.ClassNumber,             // $9
+		statusStrings(s.NotifyOn), // $10
+		s.MinOpenSeats,            // $11
+		s.WaitlistBelow,           // $12
+	)
+	return err
+}
+
+func (p *Postgres) RemoveSubscription(ctx context.Context, userEmail, courseID, courseSubjectCode string, classNumber int) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	deleteQuery := `
+		DELETE FROM subscriptions
+		WHERE user_id = (SELECT id FROM users WHERE email=$1)
+		  AND course_id = $2
+		  AND course_subject_code = $3
+		  AND ($4 = 0 OR class_number = $4)
+	`
+	if _, err := tx.Exec(ctx, deleteQuery, userEmail, courseID, courseSubjectCode, classNumber); err != nil {
+		return err
+	}
+
+	// Drop the availability records once nobody is watching the course.
+	for _, table := range []string{"course_availability", "section_availability"} {
+		cleanupQuery := `
+			DELETE FROM ` + table + `
+			WHERE course_id = $1 AND course_subject_code = $2
+			  AND NOT EXISTS (
+			    SELECT 1 FROM subscriptions
+			    WHERE course_id = $1 AND course_subject_code = $2
+			  )
+		`
+		if _, err := tx.Exec(ctx, cleanupQuery, courseID, courseSubjectCode); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *Postgres) ListSubscriptions(ctx context.Context, userEmail string) ([]Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE user_id = (SELECT id FROM users WHERE email = $1)
+	`
+	return p.querySubscriptions(ctx, query, userEmail)
+}
+
+func (p *Postgres) ListWatchedCourses(ctx context.Context) ([]WatchedCourse, error) {
+	query := `
+		SELECT MIN(course_name), course_id, course_subject_code
+		FROM subscriptions
+		GROUP BY course_id, course_subject_code
+	`
+	rows, err := p.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []WatchedCourse
+	for rows.Next() {
+		var c WatchedCourse
+		if err := rows.Scan(&c.CourseName, &c.CourseID, &c.CourseSubjectCode); err != nil {
+			return nil, err
+		}
+		courses = append(courses, c)
+	}
+	return courses, rows.Err()
+}
+
+func (p *Postgres) ListCourseSubscriptions(ctx context.Context, courseID, courseSubjectCode string) ([]Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE course_id = $1 AND course_subject_code = $2
+	`
+	return p.querySubscriptions(ctx, query, courseID, courseSubjectCode)
+}
+
+func (p *Postgres) GetCourseAvailability(ctx context.Context, courseID, courseSubjectCode string) (CourseAvailability, error) {
+	query := `
+		SELECT course_id, course_subject_code, course_name, course_status,
+		       open_seats, waitlist_size, last_checked, version
+		FROM course_availability
+		WHERE course_id = $1 AND course_subject_code = $2
+	`
+	var a CourseAvailability
+	err := p.pool.QueryRow(ctx, query, courseID, courseSubjectCode).Scan(
+		&a.CourseID,
+		&a.CourseSubjectCode,
+		&a.CourseName,
+		&a.Status,
+		&a.OpenSeats,
+		&a.WaitlistSize,
+		&a.LastChecked,
+		&a.Version,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CourseAvailability{}, ErrNotFound
+	}
+	return a, err
+}
+
+func (p *Postgres) SetCourseAvailability(ctx context.Context, a CourseAvailability) error {
+	return upsertCourseAvailability(ctx, p.pool, a)
+}
+
+func (p *Postgres) ListSectionAvailability(ctx context.Context, courseID, courseSubjectCode string) ([]SectionAvailability, error) {
+	query := `
+		SELECT course_id, course_subject_code, class_number, sections, status,
+		       open_seats, capacity, waitlist_size, last_checked
+		FROM section_availability
+		WHERE course_id = $1 AND course_subject_code = $2
+		ORDER BY class_number
+	`
+	rows, err := p.pool.Query(ctx, query, courseID, courseSubjectCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sections []SectionAvailability
+	for rows.Next() {
+		var a SectionAvailability
+		if err := rows.Scan(
+			&a.CourseID,
+			&a.CourseSubjectCode,
+			&a.ClassNumber,
+			&a.Sections,
+			&a.Status,
+			&a.OpenSeats,
+			&a.Capacity,
+			&a.WaitlistSize,
+			&a.LastChecked,
+		); err != nil {
+			return nil, err
+		}
+		sections = append(sections, a)
+	}
+	return sections, rows.Err()
+}
+
+func (p *Postgres) SetSectionAvailability(ctx context.Context, a SectionAvailability) error {
+	return upsertSectionAvailability(ctx, p.pool, a)
+}
+
+func (p *Postgres) RecordCheck(ctx context.Context, course CourseAvailability, sections []SectionAvailability, outbox []Notification) error {
+	return p.inTx(ctx, func(tx pgx.Tx) error {
+		// Swap the course row first: it is locked until commit, and a check
+		// that lost the race leaves no trace.
+		if err := swapCourseAvailability(ctx, tx, course); err != nil {
+			return err
+		}
+		for _, a := range sections {
+			if err := upsertSectionAvailability(ctx, tx, a); err != nil {
+				return err
+			}
+		}
+		for _, n := range outbox {
+			query := `
+				INSERT INTO notifications (recipient, subject, html, course_id, course_subject_code)
+				VALUES ($1, $2, $3, $4, $5)
+			`
+			if _, err := tx.Exec(ctx, query, n.Recipient, n.Subject, n.HTML, n.CourseID, n.CourseSubjectCode); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *Postgres) CreateAPIKey(ctx context.Context, k APIKey) (APIKey, error) {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := p.pool.QueryRow(ctx, query, k.UserID, k.Name, k.Prefix, k.Hash, k.Scopes).Scan(&k.ID, &k.CreatedAt)
+	return k, err
+}
+
+func (p *Postgres) ListAPIKeys(ctx context.Context, userID int64) ([]APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := p.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var k APIKey
+		if err := rows.Scan(apiKeyFields(&k)...); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (p *Postgres) RevokeAPIKey(ctx context.Context, userID, id int64) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	tag, err := p.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *Postgres) UseAPIKey(ctx context.Context, hash []byte) (APIKey, User, error) {
+	query := `
+		WITH k AS (
+		  UPDATE api_keys
+		  SET last_used_at = now()
+		  WHERE key_hash = $1 AND revoked_at IS NULL
+		  RETURNING ` + apiKeyColumns + `
+		)
+		SELECT k.*, u.id, u.email, u.google_sub, u.name, u.image, u.created_at, u.last_logged_in
+		FROM k
+		JOIN users u ON u.id = k.user_id
+	`
+	var k APIKey
+	var u User
+	fields := append(apiKeyFields(&k),
+		&u.ID,
+		&u.Email,
+		&u.GoogleSub,
+		&u.Name,
+		&u.Image,
+		&u.CreatedAt,
+		&u.LastLoggedIn,
+	)
+	err := p.pool.QueryRow(ctx, query, hash).Scan(fields...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return APIKey{}, User{}, ErrNotFound
+	}
+	return k, u, err
+}
+
+func (p *Postgres) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	// Expiry is measured on the database clock, which every instance shares.
+	query := `
+		INSERT INTO leases (name, holder, expires_at)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE SET
+		  holder = EXCLUDED.holder,
+		  expires_at = EXCLUDED.expires_at
+		WHERE leases.holder = EXCLUDED.holder OR leases.expires_at <= now()
+	`
+	tag, err := p.pool.Exec(ctx, query, name, holder, ttl.Seconds())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (p *Postgres) ReleaseLease(ctx context.Context, name, holder string) error {
+	query := `DELETE FROM leases WHERE name = $1 AND holder = $2`
+	_, err := p.pool.Exec(ctx, query, name, holder)
+	return err
+}
+
+func (p *Postgres) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]Notification, error) {
+	query := `
+		UPDATE notifications
+		SET next_attempt_at = $2
+		WHERE id IN (
+		  SELECT id FROM notifications
+		  WHERE status = 'pending' AND next_attempt_at <= now()
+		  ORDER BY next_attempt_at, id
+		  LIMIT $1
+		  FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, recipient, subject, html, course_id, course_subject_code, status,
+		          attempts, last_error, next_attempt_at, last_attempt_at, sent_at, created_at
+	`
+	rows, err := p.pool.Query(ctx, query, limit, time.Now().Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []Notification
+	for rows.Next() {
+		var n Notification
+		if err := rows.Scan(
+			&n.ID,
+			&n.Recipient,
+			&n.Subject,
+			&n.HTML,
+			&n.CourseID,
+			&n.CourseSubjectCode,
+			&n.Status,
+			&n.Attempts,
+			&n.LastError,
+			&n.NextAttemptAt,
+			&n.LastAttemptAt,
+			&n.SentAt,
+			&n.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, n)
+	}
+	return claimed, rows.Err()
+}
+
+func (p *Postgres) MarkNotificationSent(ctx context.Context, id int64) error {
+	query := `
+		UPDATE notifications
+		SET status = 'sent', attempts = attempts + 1, last_error = '',
+		    last_attempt_at = now(), sent_at = now()
+		WHERE id = $1
+	`
+	_, err := p.pool.Exec(ctx, query, id)
+	return err
+}
+
+func (p *Postgres) MarkNotificationFailed(ctx context.Context, id int64, deliveryErr string, nextAttempt time.Time, dead bool) error {
+	status := NotificationPending
+	if dead {
+		status = NotificationDead
+	}
+	query := `
+		UPDATE notifications
+		SET status = $2, attempts = attempts + 1, last_error = $3,
+		    last_attempt_at = now(), next_attempt_at = $4
+		WHERE id = $1
+	`
+	_, err := p.pool.Exec(ctx, query, id, status, deliveryErr, nextAttempt)
+	return err
+}
+
+// execer is satisfied by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// apiKeyColumns lists the api_keys columns scanned by apiKeyFields.
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at`
+
+func apiKeyFields(k *APIKey) []any {
+	return []any{&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.Hash, &k.Scopes, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt}
+}
+
+func upsertCourseAvailability(ctx context.Context, db execer, a CourseAvailability) error {
+	query := `
+		INSERT INTO course_availability (
+		  course_id, course_subject_code, course_name, course_status,
+		  open_seats, waitlist_size, last_checked
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		ON CONFLICT (course_id, course_subject_code)
+		DO UPDATE SET
+		  course_status = EXCLUDED.course_status,
+		  open_seats = EXCLUDED.open_seats,
+		  waitlist_size = EXCLUDED.waitlist_size,
+		  last_checked = EXCLUDED.last_checked,
+		  version = course_availability.version + 1
+	`
+	_, err := db.Exec(ctx, query, a.CourseID, a.CourseSubjectCode, a.CourseName, a.Status, a.OpenSeats, a.WaitlistSize)
+	return err
+}
+
+// swapCourseAvailability writes a course's availability only if the stored
+// row is still at a.Version, or still absent when a.Version is zero.
+func swapCourseAvailability(ctx context.Context, db execer, a CourseAvailability) error {
+	var tag pgconn.CommandTag
+	var err error
+	if a.Version == 0 {
+		query := `
+			INSERT INTO course_availability (
+			  course_id, course_subject_code, course_name, course_status,
+			  open_seats, waitlist_size, last_checked
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, now())
+			ON CONFLICT (course_id, course_subject_code) DO NOTHING
+		`
+		tag, err = db.Exec(ctx, query, a.CourseID, a.CourseSubjectCode, a.CourseName, a.Status, a.OpenSeats, a.WaitlistSize)
+	} else {
+		query := `
+			UPDATE course_availability
+			SET course_status = $3,
+			    open_seats = $4,
+			    waitlist_size = $5,
+			    last_checked = now(),
+			    version = version + 1
+			WHERE course_id = $1 AND course_subject_code = $2 AND version = $6
+		`
+		tag, err = db.Exec(ctx, query, a.CourseID, a.CourseSubjectCode, a.Status, a.OpenSeats, a.WaitlistSize, a.Version)
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func upsertSectionAvailability(ctx context.Context, db execer, a SectionAvailability) error {
+	query := `
+		INSERT INTO section_availability (
+		  course_id, course_subject_code, class_number, sections, status,
+		  open_seats, capacity, waitlist_size, last_checked
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+		ON CONFLICT (course_id, course_subject_code, class_number)
+		DO UPDATE SET
+		  sections = EXCLUDED.sections,
+		  status = EXCLUDED.status,
+		  open_seats = EXCLUDED.open_seats,
+		  capacity = EXCLUDED.capacity,
+		  waitlist_size = EXCLUDED.waitlist_size,
+		  last_checked = EXCLUDED.last_checked
+	`
+	_, err := db.Exec(ctx, query,
+		a.CourseID,
+		a.CourseSubjectCode,
+		a.ClassNumber,
+		a.Sections,
+		a.Status,
+		a.OpenSeats,
+		a.Capacity,
+		a.WaitlistSize,
+	)
+	return err
+}
+
+// subscriptionColumns lists the columns scanned by querySubscriptions.
+const subscriptionColumns = `user_email, user_fullname, course_id, course_subject_code, course_name,
+		       credits, title, class_number, notify_on,
+		       min_open_seats, waitlist_below, created_at`
+
+func (p *Postgres) querySubscriptions(ctx context.Context, query string, args ...any) ([]Subscription, error) {
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var s Subscription
+		var notifyOn []string
+		if err := rows.Scan(
+			&s.UserEmail,
+			&s.UserFullName,
+			&s.CourseID,
+			&s.CourseSubjectCode,
+			&s.CourseName,
+			&s.Credits,
+			&s.Title,
+			&s.ClassNumber,
+			&notifyOn,
+			&s.MinOpenSeats,
+			&s.WaitlistBelow,
+			&s.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		for _, status := range notifyOn {
+			s.NotifyOn = append(s.NotifyOn, Status(status))
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+func statusStrings(statuses []Status) []string {
+	out := make([]string, len(statuses))
+	for i, s := range statuses {
+		out[i] = string(s)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package store
+
+import "strings"
+
+// Status is the enrollment state of a course or section.
+type Status string
+
+const (
+	StatusOpen       Status = "open"        // seats are available
+	StatusWaitlisted Status = "waitlisted"  // full, but the waitlist is open
+	StatusClosed     Status = "closed"      // full with no waitlist space
+	StatusNotOffered Status = "not_offered" // not offered this term, or cancelled
+)
+
+// ParseStatus converts an enroll API package status such as "OPEN" or
+// "WAITLISTED" into a Status. Unrecognised values are treated as closed.
+func ParseStatus(s string) Status {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "OPEN":
+		return StatusOpen
+	case "WAITLISTED":
+		return StatusWaitlisted
+	case "NOT_OFFERED", "CANCELLED":
+		return StatusNotOffered
+	default:
+		return StatusClosed
+	}
+}
+
+// Valid reports whether s is one of the defined statuses.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusOpen, StatusWaitlisted, StatusClosed, StatusNotOffered:
+		return true
+	}
+	return false
+}
+
+// Rank orders statuses from least to most enrollable, so the status of a
+// course is the highest-ranked status among its sections.
+func (s Status) Rank() int {
+	switch s {
+	case StatusOpen:
+		return 3
+	case StatusWaitlisted:
+		return 2
+	case StatusClosed:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String returns a human-readable description for notifications.
+func (s Status) String() string {
+	if s == StatusNotOffered {
+		return "not offered"
+	}
+	return string(s)
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a requested record does not exist.
+var ErrNotFound = errors.New("store: not found")
+
+// ErrConflict is returned by RecordCheck when the course was recorded by
+// someone else after the check read it.
+var ErrConflict = errors.New("store: concurrent update")
+
+// User is a row in the users table.
+type User struct {
+	ID           int64
+	Email        string
+	GoogleSub    string
+	Name         string
+	Image        string
+	CreatedAt    time.Time
+	LastLoggedIn time.Time
+}
+
+// Subscription is a row in the subscriptions table. A ClassNumber of zero
+// watches the whole course; otherwise it watches one enrollment package
+// (section combination) identified by its class number. NotifyOn limits
+// notifications to changes into the listed statuses; empty means any change.
+// MinOpenSeats and WaitlistBelow, when set, replace "any status change" with
+// "at least N open seats" or "waitlist shorter than M" conditions.
+type Subscription struct {
+	UserEmail         string
+	UserFullName      string
+	CourseID          string
+	CourseName        string
+	CourseSubjectCode string
+	ClassNumber       int
+	NotifyOn          []Status
+	MinOpenSeats      *int
+	WaitlistBelow     *int
+	Credits           int
+	Title             string
+	CreatedAt         time.Time
+}
+
+// Wants reports whether the subscriber asked to hear about a change into status.
+func (s Subscription) Wants(status Status) bool {
+	if len(s.NotifyOn) == 0 {
+		return true
+	}
+	for _, want := range s.NotifyOn {
+		if want == status {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchedCourse is a course that at least one user is subscribed to.
+type WatchedCourse struct {
+	CourseID          string
+	CourseSubjectCode string
+	CourseName        string
+}
+
+// CourseAvailability is a row in the course_availability table. Version is
+// bumped on every write; zero means the course has never been recorded.
+type CourseAvailability struct {
+	CourseID          string
+	CourseSubjectCode string
+	CourseName        string
+	Status            Status
+	OpenSeats         int
+	WaitlistSize      int
+	LastChecked       time.Time
+	Version           int64
+}
+
+// SectionAvailability is a row in the section_availability table, holding the
+// last observed state of one enrollment package of a course.
+type SectionAvailability struct {
+	CourseID          string
+	CourseSubjectCode string
+	ClassNumber       int
+	Sections          string // e.g. "LEC 001 / DIS 301"
+	Status            Status
+	OpenSeats         int
+	Capacity          int
+	WaitlistSize      int
+	LastChecked       time.Time
+}
+
+// Store is the persistence layer shared by every API handler and the cron job.
+type Store interface {
+	// UpsertUser inserts a user keyed by email, or refreshes google_sub, name,
+	// image and last_logged_in if the email already exists.
+	UpsertUser(ctx context.Context, u User) error
+
+	// GetUser returns the user with the given email, or ErrNotFound if they
+	// have never registered.
+	GetUser(ctx context.Context, email string) (User, error)
+
+	// AddSubscription subscribes the user identified by s.UserEmail to a course,
+	// or to one of its sections when s.ClassNumber is set. Subscribing twice to
+	// the same course and section updates the stored course details.
+	AddSubscription(ctx context.Context, s Subscription) error
+
+	// RemoveSubscription deletes a user's subscription to the section with the
+	// given class number, or every subscription to the course when classNumber
+	// is zero. When no subscribers remain, the course's availability records
+	// are deleted as well.
+	RemoveSubscription(ctx context.Context, userEmail, courseID, courseSubjectCode string, classNumber int) error
+
+	// ListSubscriptions returns every subscription held by the given user.
+	ListSubscriptions(ctx context.Context, userEmail string) ([]Subscription, error)
+
+	// ListWatchedCourses returns each distinct course that has subscribers,
+	// identified by course ID and subject code. Should subscribers have saved
+	// different names for it, the least is returned.
+	ListWatchedCourses(ctx context.Context) ([]WatchedCourse, error)
+
+	// ListCourseSubscriptions returns every subscription to a course or any of
+	// its sections.
+	ListCourseSubscriptions(ctx context.Context, courseID, courseSubjectCode string) ([]Subscription, error)
+
+	// GetCourseAvailability returns the last recorded availability of a course,
+	// or ErrNotFound if it has never been checked.
+	GetCourseAvailability(ctx context.Context, courseID, courseSubjectCode string) (CourseAvailability, error)
+
+	// SetCourseAvailability records the current availability of a course.
+	SetCourseAvailability(ctx context.Context, a CourseAvailability) error
+
+	// ListSectionAvailability returns the last recorded state of every section
+	// of a course.
+	ListSectionAvailability(ctx context.Context, courseID, courseSubjectCode string) ([]SectionAvailability, error)
+
+	// SetSectionAvailability records the current state of one section.
+	SetSectionAvailability(ctx context.Context, a SectionAvailability) error
+
+	// RecordCheck atomically stores the result of checking one course: its
+	// availability, the state of its sections, and the notifications the
+	// change produced, which are queued in the outbox as pending. course.Version
+	// must be the version the check started from; if the stored row has moved
+	// on since, nothing is written and ErrConflict is returned.
+	RecordCheck(ctx context.Context, course CourseAvailability, sections []SectionAvailability, outbox []Notification) error
+
+	// ClaimNotifications returns up to limit pending notifications that are
+	// due, and pushes their next attempt back by lease so that concurrent
+	// dispatchers do not pick up the same entries.
+	ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]Notification, error)
+
+	// MarkNotificationSent records a successful delivery attempt.
+	MarkNotificationSent(ctx context.Context, id int64) error
+
+	// MarkNotificationFailed records a failed delivery attempt. The entry is
+	// retried at nextAttempt, or marked dead if dead is true.
+	MarkNotificationFailed(ctx context.Context, id int64, deliveryErr string, nextAttempt time.Time, dead bool) error
+
+	// CreateAPIKey stores a new API key and returns it with its ID and
+	// creation time filled in.
+	CreateAPIKey(ctx context.Context, k APIKey) (APIKey, error)
+
+	// ListAPIKeys returns the user's keys that have not been revoked, newest
+	// first.
+	ListAPIKeys(ctx context.Context, userID int64) ([]APIKey, error)
+
+	// RevokeAPIKey revokes one of the user's keys, or returns ErrNotFound if
+	// the user has no such unrevoked key.
+	RevokeAPIKey(ctx context.Context, userID, id int64) error
+
+	// UseAPIKey returns the unrevoked key with the given hash together with
+	// its owner, and records that it was used. It returns ErrNotFound for an
+	// unknown or revoked key.
+	UseAPIKey(ctx context.Context, hash []byte) (APIKey, User, error)
+
+	// AcquireLease takes the named lease for holder until ttl from now. It
+	// reports false if another holder has an unexpired lease under that name.
+	// A holder may re-acquire its own lease to extend it.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// ReleaseLease gives up the named lease if holder still has it.
+	ReleaseLease(ctx context.Context, name, holder string) error
+
+	// Ping checks that the store can serve queries.
+	Ping(ctx context.Context) error
+
+	// Close releases any resources held by the store.
+	Close()
+}
@@ -6,9 +6,9 @@ import NotificationsNoneIcon from "@mui/icons-material/NotificationsNone";
 import NotificationsOffIcon from "@mui/icons-material/NotificationsOff";
 import useSWR from "swr";
 import { useDisclosure } from "@heroui/react";
-import { useSession } from "next-auth/react";
+import { signIn, useSession } from "next-auth/react";
 
-import { fetchCourses } from "@/lib/api";
+import { backendFetch, fetchCourses, fetchSubscriptions } from "@/lib/api";
 import SubscribeModal from "@/components/subscribeModal";
 import UnsubscribeModal from "@/components/unsubscribeModal";
 
@@ -57,10 +57,17 @@ export default function CoursesPage() {
     // If authenticated, fetch subscriptions for the current user
     const { data: subsData, mutate: mutateSubscriptions } = useSWR<{ subscriptions: Subscription[] }>(
         isAuthenticated && session?.user?.email ? [`subscriptions`, session.user.email] : null,
-        () => fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/subscriptions?userEmail=${session?.user?.email}`).then((res) => res.json()),
+        () => fetchSubscriptions<Subscription>(session?.idToken),
         { refreshInterval: 60000 }
     );
 
+    // The ID token could not be refreshed, so the user has to sign in again.
+    useEffect(() => {
+        if (session?.error === "RefreshTokenError") {
+            signIn("google");
+        }
+    }, [session?.error]);
+
     const paginatedData = data?.hits || [];
     const totalResults = data?.found || 0;
     const termShortDescription = data?.term.shortDescription || "";
@@ -98,8 +105,6 @@ export default function CoursesPage() {
             return;
         }
         const payload = {
-            userEmail: session.user.email,
-            userFullName: session.user.name,
             courseId: course.id,
             courseName: course.name,
             courseSubjectCode: course.subjectCode,
@@ -108,7 +113,7 @@ export default function CoursesPage() {
         };
 
         try {
-            const response = await fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/subscribe`, {
+            const response = await backendFetch("/api/subscribe", session.idToken, {
                 method: "POST",
                 headers: { "Content-Type": "application/json" },
                 body: JSON.stringify(payload),
@@ -143,13 +148,12 @@ export default function CoursesPage() {
             return;
         }
         const payload = {
-            userEmail: session.user.email,
             courseId: course.id,
             courseSubjectCode: course.subjectCode,
         };
 
         try {
-            const response = await fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/unsubscribe`, {
+            const response = await backendFetch("/api/unsubscribe", session.idToken, {
                 method: "POST", // Or DELETE if your endpoint supports it
                 headers: { "Content-Type": "application/json" },
                 body: JSON.stringify(payload),
@@ -4,7 +4,9 @@ import React, { useEffect, useState } from "react";
 import { Card, CardBody, Button, Spinner, Alert } from "@heroui/react";
 import NotificationsOffIcon from "@mui/icons-material/NotificationsOff";
 import useSWR from "swr";
-import { useSession } from "next-auth/react";
+import { signIn, useSession } from "next-auth/react";
+
+import { backendFetch, fetchSubscriptions } from "@/lib/api";
 
 // Reuse the same interfaces from your home page:
 interface Subscription {
@@ -32,10 +34,17 @@ export default function MySubscriptionsPage() {
         mutate,
     } = useSWR<{ subscriptions: Subscription[] }>(
         isAuthenticated && session?.user?.email ? [`subscriptions`, session.user.email] : null,
-        () => fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/subscriptions?userEmail=${session?.user?.email}`).then((res) => res.json()),
+        () => fetchSubscriptions<Subscription>(session?.idToken),
         { refreshInterval: 60000 }
     );
 
+    // The ID token could not be refreshed, so the user has to sign in again.
+    useEffect(() => {
+        if (session?.error === "RefreshTokenError") {
+            signIn("google");
+        }
+    }, [session?.error]);
+
     // Subscriptions array from the backend
     const subscriptions = subsData?.subscriptions || [];
 
@@ -60,13 +69,12 @@ export default function MySubscriptionsPage() {
 
         // Payload for unsubscribe
         const payload = {
-            userEmail: session.user.email,
             courseId: subscription.courseId,
             courseSubjectCode: subscription.courseSubjectCode,
         };
 
         try {
-            const response = await fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/unsubscribe`, {
+            const response = await backendFetch("/api/unsubscribe", session.idToken, {
                 method: "POST",
                 headers: { "Content-Type": "application/json" },
                 body: JSON.stringify(payload),
@@ -1,31 +1,32 @@
 import NextAuth from "next-auth";
 import GoogleProvider from "next-auth/providers/google";
 
+// Refresh the ID token a minute before it expires, so that a request made
+// just before expiry does not reach the backend with a stale token.
+const refreshMarginSeconds = 60;
+
 export const { handlers, signIn, signOut, auth } = NextAuth({
     providers: [
         GoogleProvider({
             clientId: process.env.GOOGLE_CLIENT_ID!,
             clientSecret: process.env.GOOGLE_CLIENT_SECRET!,
+            // Ask for a refresh token, which Google only issues with consent.
+            authorization: { params: { access_type: "offline", prompt: "consent", response_type: "code" } },
         }),
     ],
     callbacks: {
-        async signIn({ user, profile }) {
+        async signIn({ account }) {
             try {
-                const googleSub = profile?.sub;
-
-                await fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/register`, {
+                // The backend takes the user's details from the verified ID token.
+                const response = await fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/register`, {
                     method: "POST",
-                    headers: { "Content-Type": "application/json" },
-                    body: JSON.stringify({
-                        user: {
-                            name: user.name,
-                            email: user.email,
-                            image: user.image,
-                            googleSub,
-                        },
-                    }),
+                    headers: { Authorization: `Bearer ${account?.id_token}` },
                 });
 
+                if (!response.ok) {
+                    throw new Error(`Register failed with status ${response.status}`);
+                }
+
                 // If we successfully stored the user, return true to allow sign-in
                 return true;
             } catch (err) {
@@ -36,5 +37,63 @@ export const { handlers, signIn, signOut, auth } = NextAuth({
                 return false;
             }
         },
+        async jwt({ token, account }) {
+            // Keep Google's ID token so the backend can verify who is calling,
+            // with the refresh token needed to renew it after about an hour.
+            if (account) {
+                return {
+                    ...token,
+                    idToken: account.id_token,
+                    expiresAt: account.expires_at,
+                    refreshToken: account.refresh_token,
+                    error: undefined,
+                };
+            }
+
+            if (token.expiresAt && Date.now() / 1000 < token.expiresAt - refreshMarginSeconds) {
+                return token;
+            }
+            if (!token.refreshToken) {
+                return { ...token, error: "RefreshTokenError" as const };
+            }
+
+            try {
+                const response = await fetch("https://oauth2.googleapis.com/token", {
+                    method: "POST",
+                    body: new URLSearchParams({
+                        client_id: process.env.GOOGLE_CLIENT_ID!,
+                        client_secret: process.env.GOOGLE_CLIENT_SECRET!,
+                        grant_type: "refresh_token",
+                        refresh_token: token.refreshToken,
+                    }),
+                });
+                const refreshed = await response.json();
+
+                if (!response.ok || !refreshed.id_token) {
+                    throw new Error(`Token refresh failed with status ${response.status}: ${refreshed.error ?? "no id_token"}`);
+                }
+
+                return {
+                    ...token,
+                    idToken: refreshed.id_token,
+                    expiresAt: Math.floor(Date.now() / 1000 + refreshed.expires_in),
+                    // Google usually keeps the same refresh token.
+                    refreshToken: refreshed.refresh_token ?? token.refreshToken,
+                    error: undefined,
+                };
+            } catch (err) {
+                // eslint-disable-next-line no-console
+                console.error("Failed to refresh Google ID token:", err);
+
+                // The client sends the user back through sign-in.
+                return { ...token, error: "RefreshTokenError" as const };
+            }
+        },
+        async session({ session, token }) {
+            session.idToken = token.idToken;
+            session.error = token.error;
+
+            return session;
+        },
     },
 });
@@ -1,3 +1,33 @@
+import { signIn } from "next-auth/react";
+
+// backendFetch calls an authenticated backend endpoint with the session's
+// Google ID token. A 401 means the token has expired or been revoked, so the
+// user is sent back through sign-in instead of the caller parsing the error.
+export async function backendFetch(path: string, idToken: string | undefined, init: RequestInit = {}) {
+    const response = await fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}${path}`, {
+        ...init,
+        headers: { ...(init.headers as Record<string, string>), Authorization: `Bearer ${idToken}` },
+    });
+
+    if (response.status === 401) {
+        await signIn("google");
+        throw new Error("Session expired, signing in again");
+    }
+
+    return response;
+}
+
+// fetchSubscriptions returns the signed-in user's subscriptions.
+export async function fetchSubscriptions<T>(idToken: string | undefined): Promise<{ subscriptions: T[] }> {
+    const response = await backendFetch("/api/subscriptions", idToken);
+
+    if (!response.ok) {
+        throw new Error(`Fetching subscriptions failed with status: ${response.status}`);
+    }
+
+    return response.json();
+}
+
 export async function fetchCourses(page: number = 1, pageSize: number = 50, query: string = "*") {
     try {
         const response = await fetch(
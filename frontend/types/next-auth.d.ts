@@ -0,0 +1,19 @@
+import "next-auth";
+import "next-auth/jwt";
+
+declare module "next-auth" {
+    interface Session {
+        idToken?: string;
+        // Set when the ID token could not be refreshed; the user must sign in again.
+        error?: "RefreshTokenError";
+    }
+}
+
+declare module "next-auth/jwt" {
+    interface JWT {
+        idToken?: string;
+        expiresAt?: number; // Unix seconds
+        refreshToken?: string;
+        error?: "RefreshTokenError";
+    }
+}